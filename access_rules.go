@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kuachi/terraform-provider-ovh/ovh"
+	"io"
+	"os"
+	"strings"
+)
+
+const accessRulesUsage = `Usage: terraform-provider-ovh access-rules [options] [TYPE...]
+
+Prints the OVH API access rules the provider needs to manage the given
+resource and data source types, or every type when none is given.
+
+Types: %s
+
+Options:
+`
+
+// accessRulesCommand runs the access-rules subcommand with args, returning
+// the exit status.
+func accessRulesCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("access-rules", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, accessRulesUsage, strings.Join(ovh.AccessRuleTypes(), ", "))
+		flags.PrintDefaults()
+	}
+
+	asJSON := flags.Bool("json", false, "print the rules as the accessRules JSON array of /auth/credential")
+	request := flags.Bool("request-credential", false, "request a consumer key granted the rules and print its validation URL")
+	redirection := flags.String("redirection", "", "URL to redirect to once the consumer key is validated")
+	endpoint := flags.String("endpoint", os.Getenv("OVH_ENDPOINT"), "OVH API endpoint, defaults to OVH_ENDPOINT")
+	applicationKey := flags.String("application-key", os.Getenv("OVH_APPLICATION_KEY"), "application key, defaults to OVH_APPLICATION_KEY")
+	applicationSecret := flags.String("application-secret", os.Getenv("OVH_APPLICATION_SECRET"), "application secret, defaults to OVH_APPLICATION_SECRET")
+	configFile := flags.String("config-file", os.Getenv("OVH_CONFIG_FILE"), "ovh.conf file to read instead of the default locations")
+	profile := flags.String("profile", os.Getenv("OVH_PROFILE"), "ovh.conf section to read the endpoint and application from")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	rules, err := ovh.AccessRulesFor(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		b, err := json.MarshalIndent(rules, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(b))
+	} else {
+		for _, rule := range rules {
+			fmt.Fprintln(stdout, rule)
+		}
+	}
+
+	if !*request {
+		return 0
+	}
+
+	config := &ovh.Config{
+		Endpoint:          *endpoint,
+		ApplicationKey:    *applicationKey,
+		ApplicationSecret: *applicationSecret,
+		ConfigFile:        *configFile,
+		Profile:           *profile,
+	}
+
+	state, err := config.RequestConsumerKey(rules, *redirection)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "\nConsumer key: %s\nValidate it at: %s\n", state.ConsumerKey, state.ValidationURL)
+	return 0
+}
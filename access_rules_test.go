@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessRulesCommand(t *testing.T) {
+	srv := ovhtest.NewServer()
+	defer srv.Close()
+
+	// an empty ovh.conf keeps the command from reading the credentials of
+	// the user running the tests.
+	dir, err := ioutil.TempDir("", "terraform-provider-ovh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "ovh.conf")
+	if err := ioutil.WriteFile(configFile, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	request := []string{
+		"-request-credential",
+		"-config-file", configFile,
+		"-endpoint", srv.URL,
+		"-application-secret", srv.ApplicationSecret,
+	}
+
+	cases := []struct {
+		name   string
+		args   []string
+		status int
+		stdout []string
+		stderr []string
+	}{
+		{
+			name:   "text",
+			args:   []string{"ovh_publiccloud_private_network"},
+			stdout: []string{"POST /cloud/project/*/network/private/*/region\n"},
+		},
+		{
+			name:   "json",
+			args:   []string{"-json", "ovh_publiccloud_private_network"},
+			stdout: []string{`"method": "POST"`, `"path": "/cloud/project/*/network/private/*/region"`},
+		},
+		{
+			name:   "unknown type",
+			args:   []string{"ovh_unknown"},
+			status: 1,
+			stderr: []string{`unknown resource or data source type "ovh_unknown"`},
+		},
+		{
+			name:   "bad flag",
+			args:   []string{"-unknown"},
+			status: 2,
+			stderr: []string{"Usage: terraform-provider-ovh access-rules"},
+		},
+		{
+			name:   "request credential",
+			args:   append(append([]string{}, request...), "-application-key", srv.ApplicationKey, "ovh_publiccloud_private_network"),
+			stdout: []string{"POST /cloud/project/*/network/private/*/region\n"},
+			stderr: []string{"Consumer key: fake-pending-consumer-key-", "Validate it at: " + srv.URL + "/auth/sso/"},
+		},
+		{
+			name:   "request credential without endpoint",
+			args:   []string{"-request-credential", "-config-file", configFile, "-endpoint", "", "ovh_publiccloud_private_network"},
+			status: 1,
+			stderr: []string{"endpoint must be set"},
+		},
+		{
+			name:   "request credential with an unknown application",
+			args:   append(append([]string{}, request...), "-application-key", "unknown", "ovh_publiccloud_private_network"),
+			status: 1,
+			stderr: []string{"Post /auth/credential", "Invalid application key"},
+		},
+	}
+
+	for _, c := range cases {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		if status := accessRulesCommand(c.args, stdout, stderr); status != c.status {
+			t.Errorf("%s: expected status %d, got %d:\n%s", c.name, c.status, status, stderr)
+		}
+		for _, s := range c.stdout {
+			if !strings.Contains(stdout.String(), s) {
+				t.Errorf("%s: expected %q in output, got:\n%s", c.name, s, stdout)
+			}
+		}
+		for _, s := range c.stderr {
+			if !strings.Contains(stderr.String(), s) {
+				t.Errorf("%s: expected %q in errors, got:\n%s", c.name, s, stderr)
+			}
+		}
+	}
+}
@@ -3,9 +3,14 @@ package main
 import (
 	"github.com/hashicorp/terraform/plugin"
 	"github.com/kuachi/terraform-provider-ovh/ovh"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "access-rules" {
+		os.Exit(accessRulesCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: ovh.Provider,
 	})
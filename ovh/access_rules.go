@@ -0,0 +1,221 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
+	"github.com/ovh/go-ovh/ovh"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AccessRule grants a consumer key the calls of Method on the paths
+// matching Path, where "*" matches any sequence of characters.
+type AccessRule struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+func (r AccessRule) String() string {
+	return fmt.Sprintf("%s %s", r.Method, r.Path)
+}
+
+// Matches tells if the rule grants calling method on path.
+func (r AccessRule) Matches(method, path string) bool {
+	if r.Method != method {
+		return false
+	}
+
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(r.Path), `\*`, ".*", -1) + "$"
+	return regexp.MustCompile(pattern).MatchString(path)
+}
+
+// providerAccessRules are the API calls made on provider configuration.
+var providerAccessRules = []AccessRule{
+	{"GET", "/me"},
+	{"GET", currentCredentialPath},
+}
+
+// resourceAccessRules maps resource and data source types to the API calls
+// their operations make. Each file declares the rules of its type in a
+// xxxAccessRules var next to the schema, to be kept up to date along with
+// the calls.
+var resourceAccessRules = map[string][]AccessRule{
+	"ovh_vrack_publiccloud_attachment":       vrackPublicCloudAttachmentAccessRules,
+	"ovh_publiccloud_private_network":        pcpnAccessRules,
+	"ovh_publiccloud_private_network_subnet": pcpnsAccessRules,
+	"ovh_publiccloud_user":                   pcuAccessRules,
+	"ovh_publiccloud_user_s3_credential":     pcus3cAccessRules,
+	"ovh_publiccloud_user_s3_policy":         pcus3pAccessRules,
+	"ovh_publiccloud_failover_ip":            publicCloudFailoverIpAccessRules,
+	"ovh_publiccloud_user_roles":             dataSourcePublicCloudUserRolesAccessRules,
+	"ovh_current_credential":                 dataSourceCurrentCredentialAccessRules,
+}
+
+// AccessRuleTypes returns the sorted resource and data source types
+// declaring access rules.
+func AccessRuleTypes() []string {
+	types := make([]string, 0, len(resourceAccessRules))
+	for t := range resourceAccessRules {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// AccessRulesFor returns the sorted access rules needed by the provider
+// and the resource and data source types, all types when none is given.
+func AccessRulesFor(types []string) ([]AccessRule, error) {
+	if len(types) == 0 {
+		types = AccessRuleTypes()
+	}
+
+	rules := append([]AccessRule{}, providerAccessRules...)
+	for _, t := range types {
+		typeRules, ok := resourceAccessRules[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource or data source type %q, expected one of %s", t, strings.Join(AccessRuleTypes(), ", "))
+		}
+		rules = append(rules, typeRules...)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Path != rules[j].Path {
+			return rules[i].Path < rules[j].Path
+		}
+		return accessRuleMethodOrder[rules[i].Method] < accessRuleMethodOrder[rules[j].Method]
+	})
+
+	unique := make([]AccessRule, 0, len(rules))
+	for i, rule := range rules {
+		if i == 0 || rule != rules[i-1] {
+			unique = append(unique, rule)
+		}
+	}
+	return unique, nil
+}
+
+var accessRuleMethodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "DELETE": 3}
+
+// apiPathPatterns returns the paths of every access rule, with "*" in
+// place of identifiers, used to suggest rules granting a refused call to
+// every object rather than to a single one.
+func apiPathPatterns() []string {
+	rules, _ := AccessRulesFor(nil)
+
+	patterns := []string{}
+	for i, rule := range rules {
+		if i == 0 || rule.Path != rules[i-1].Path {
+			patterns = append(patterns, rule.Path)
+		}
+	}
+	return patterns
+}
+
+// RequestConsumerKey runs the /auth/credential flow, asking for a consumer
+// key granted rules for the application of the configuration. The returned
+// state holds the key along with the URL its owner must visit to validate
+// it, before being redirected to redirection when set.
+func (c *Config) RequestConsumerKey(rules []AccessRule, redirection string) (*ovh.CkValidationState, error) {
+	if err := c.loadConfigFile(); err != nil {
+		return nil, err
+	}
+
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint must be set with -endpoint, OVH_ENDPOINT or the [default] section of an ovh.conf file")
+	}
+
+	client, err := clientDefault(c)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting ovh client: %q\n", err)
+	}
+
+	ck := client.NewCkRequestWithRedirection(redirection)
+	for _, rule := range rules {
+		ck.AddRule(rule.Method, rule.Path)
+	}
+
+	state, err := ck.Do()
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] calling Post /auth/credential:\n\t %q", err)
+	}
+	return state, nil
+}
+
+// accessRuleFor returns the rule granting method on path, path being
+// generalized with the matching apiPathPatterns entry, if any.
+func accessRuleFor(method, path string) AccessRule {
+	path = strings.SplitN(path, "?", 2)[0]
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, pattern := range apiPathPatterns() {
+		ps := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(ps) != len(segments) {
+			continue
+		}
+
+		matches := true
+		for i := range ps {
+			if ps[i] != "*" && ps[i] != segments[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return AccessRule{Method: method, Path: pattern}
+		}
+	}
+
+	return AccessRule{Method: method, Path: path}
+}
+
+// AccessDeniedError is an API 403 for a call the consumer key isn't
+// granted, along with the rule it lacks.
+type AccessDeniedError struct {
+	Rule    AccessRule
+	Granted []AccessRule
+	Err     error
+}
+
+func (e *AccessDeniedError) Error() string {
+	granted := make([]string, 0, len(e.Granted))
+	for _, rule := range e.Granted {
+		granted = append(granted, rule.String())
+	}
+
+	return fmt.Sprintf("consumer key lacks %s; add this rule to the access rules of the consumer key, or create a new one with it (current rules: %s): %s",
+		e.Rule, strings.Join(granted, ", "), e.Err)
+}
+
+func (e *AccessDeniedError) Unwrap() error {
+	return e.Err
+}
+
+// explainForbidden turns the 403 err of calling method on path into an
+// AccessDeniedError when the access rules of the consumer key don't grant
+// the call. err is returned as is when they do or can't be read.
+func (c *OVHClient) explainForbidden(method, path string, err error) error {
+	if !ovherr.IsForbidden(err) || path == currentCredentialPath {
+		return err
+	}
+
+	credential, credErr := GetCurrentCredential(c)
+	if credErr != nil {
+		log.Printf("[WARN] couldn't read the access rules of the consumer key: %s", credErr)
+		return err
+	}
+
+	rulePath := strings.SplitN(path, "?", 2)[0]
+	for _, rule := range credential.Rules {
+		if rule.Matches(method, rulePath) {
+			return err
+		}
+	}
+
+	return &AccessDeniedError{
+		Rule:    accessRuleFor(method, path),
+		Granted: credential.Rules,
+		Err:     err,
+	}
+}
@@ -0,0 +1,196 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"strings"
+	"testing"
+)
+
+func TestAccessRuleMatches(t *testing.T) {
+	cases := []struct {
+		rule    AccessRule
+		method  string
+		path    string
+		matches bool
+	}{
+		{AccessRule{"GET", "/*"}, "GET", "/cloud/project/abc/user", true},
+		{AccessRule{"GET", "/*"}, "POST", "/cloud/project/abc/user", false},
+		{AccessRule{"POST", "/cloud/project/*/user"}, "POST", "/cloud/project/abc/user", true},
+		{AccessRule{"POST", "/cloud/project/*/user"}, "POST", "/cloud/project/abc/user/1/regeneratePassword", false},
+		{AccessRule{"DELETE", "/cloud/project/abc/*"}, "DELETE", "/cloud/project/abc/network/private/pn-1", true},
+		{AccessRule{"DELETE", "/cloud/project/abc/*"}, "DELETE", "/cloud/project/def/network/private/pn-1", false},
+		{AccessRule{"GET", "/me"}, "GET", "/me", true},
+		{AccessRule{"GET", "/me"}, "GET", "/me/bill", false},
+	}
+
+	for _, c := range cases {
+		if matches := c.rule.Matches(c.method, c.path); matches != c.matches {
+			t.Errorf("%s on %s %s: expected %v, got %v", c.rule, c.method, c.path, c.matches, matches)
+		}
+	}
+}
+
+func TestAccessRuleFor(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		rule   string
+	}{
+		{"POST", "/cloud/project/abc/network/private", "POST /cloud/project/*/network/private"},
+		{"DELETE", "/cloud/project/abc/network/private/pn-1/subnet/subnet-1", "DELETE /cloud/project/*/network/private/*/subnet/*"},
+		{"GET", "/cloud/project/abc/user/1/openrc?region=GRA1&version=v3", "GET /cloud/project/*/user/*/openrc"},
+		{"GET", "/vrack/pn-1/task/42", "GET /vrack/*/task/*"},
+		{"GET", "/me", "GET /me"},
+		{"GET", "/dedicated/server", "GET /dedicated/server"},
+	}
+
+	for _, c := range cases {
+		if rule := accessRuleFor(c.method, c.path).String(); rule != c.rule {
+			t.Errorf("%s %s: expected %s, got %s", c.method, c.path, c.rule, rule)
+		}
+	}
+}
+
+func TestOVHClient_accessDenied(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	srv.SetAccessRules(ovhtest.AccessRule{Method: "GET", Path: "/*"})
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetwork().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"name":       "network",
+		"regions":    []interface{}{"GRA1"},
+	})
+
+	err := resourcePublicCloudPrivateNetworkCreate(d, config)
+	if err == nil || !strings.Contains(err.Error(), "consumer key lacks POST /cloud/project/*/network/private; add this rule") {
+		t.Fatalf("expected an error naming the missing rule, got %v", err)
+	}
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	err = config.OVHClient.Post(endpoint, &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network"}, nil)
+	if denied, ok := err.(*AccessDeniedError); !ok || len(denied.Granted) != 1 || !ovherr.IsForbidden(err) {
+		t.Errorf("expected a forbidden AccessDeniedError listing the granted rule, got %#v", err)
+	}
+
+	if err := config.OVHClient.Get(endpoint, &[]pcpnResponse{}); err != nil {
+		t.Errorf("unexpected error on a granted call: %s", err)
+	}
+}
+
+func TestAccessRulesFor(t *testing.T) {
+	rules, err := AccessRulesFor([]string{"ovh_publiccloud_user_s3_policy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"GET /auth/currentCredential",
+		"GET /cloud/project/*/user/*/policy",
+		"POST /cloud/project/*/user/*/policy",
+		"GET /me",
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("expected rules %v, got %v", expected, rules)
+	}
+	for i := range rules {
+		if rules[i].String() != expected[i] {
+			t.Errorf("expected rule %d to be %s, got %s", i, expected[i], rules[i])
+		}
+	}
+
+	if _, err := AccessRulesFor([]string{"ovh_domain_zone"}); err == nil {
+		t.Errorf("expected an error for an unknown type")
+	}
+}
+
+func TestAccessRulesFor_everyType(t *testing.T) {
+	provider := Provider().(*schema.Provider)
+
+	types := map[string]bool{}
+	for name := range provider.ResourcesMap {
+		types[name] = true
+	}
+	for name := range provider.DataSourcesMap {
+		types[name] = true
+	}
+
+	for name := range types {
+		if _, ok := resourceAccessRules[name]; !ok {
+			t.Errorf("%s declares no access rules", name)
+		}
+	}
+	for name := range resourceAccessRules {
+		if !types[name] {
+			t.Errorf("access rules are declared for %s which the provider doesn't have", name)
+		}
+	}
+}
+
+func TestAccessRulesFor_privateNetworkLifecycle(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	rules, err := AccessRulesFor([]string{"ovh_publiccloud_private_network"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	granted := []ovhtest.AccessRule{}
+	for _, rule := range rules {
+		granted = append(granted, ovhtest.AccessRule{Method: rule.Method, Path: rule.Path})
+	}
+	srv.SetAccessRules(granted...)
+
+	r := resourcePublicCloudPrivateNetwork()
+
+	diff, err := r.Diff(nil, testPcpnResourceConfig("GRA1"), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	state, err := r.Apply(nil, diff, config)
+	if err != nil {
+		t.Fatalf("create: unexpected error: %s", err)
+	}
+
+	diff, err = r.Diff(state, testPcpnResourceConfig("GRA1", "WAW1"), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("update: unexpected error: %s", err)
+	}
+
+	if _, err = r.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("delete: unexpected error: %s", err)
+	}
+}
+
+func TestRequestConsumerKey(t *testing.T) {
+	srv := ovhtest.NewServer()
+	defer srv.Close()
+
+	config := &Config{
+		Endpoint:          srv.URL,
+		ApplicationKey:    srv.ApplicationKey,
+		ApplicationSecret: srv.ApplicationSecret,
+	}
+
+	rules, _ := AccessRulesFor(nil)
+	state, err := config.RequestConsumerKey(rules, "https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state.ConsumerKey == "" || state.ValidationURL == "" || state.State != "pendingValidation" {
+		t.Errorf("expected a consumer key pending validation, got %s", state)
+	}
+
+	config.ApplicationKey = "unknown-application-key"
+	if _, err := config.RequestConsumerKey(rules, ""); err == nil {
+		t.Errorf("expected an error for an unknown application key")
+	}
+}
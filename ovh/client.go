@@ -0,0 +1,144 @@
+package ovh
+
+import (
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
+	"github.com/ovh/go-ovh/ovh"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default bounds of the wait between two attempts of a call.
+const (
+	defaultMinBackoff = 1 * time.Second
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// OVHClient wraps the go-ovh client to retry calls failing on transient
+// errors: 429, 5xx and connection errors. Only GET, PUT and DELETE are
+// retried, POST calls are sent once unless made with PostIdempotent.
+type OVHClient struct {
+	*ovh.Client
+
+	// MaxRetries is the number of times a call is retried, 0 disabling
+	// retries.
+	MaxRetries int
+
+	// MinBackoff and MaxBackoff bound the exponential wait between two
+	// attempts, defaulting to defaultMinBackoff and defaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewOVHClient wraps client to retry failing calls up to maxRetries times.
+func NewOVHClient(client *ovh.Client, maxRetries int) *OVHClient {
+	return &OVHClient{
+		Client:     client,
+		MaxRetries: maxRetries,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+	}
+}
+
+func (c *OVHClient) Get(url string, resType interface{}) error {
+	return c.call("GET", url, nil, resType, true)
+}
+
+func (c *OVHClient) Put(url string, reqBody, resType interface{}) error {
+	return c.call("PUT", url, reqBody, resType, true)
+}
+
+func (c *OVHClient) Delete(url string, resType interface{}) error {
+	return c.call("DELETE", url, nil, resType, true)
+}
+
+func (c *OVHClient) Post(url string, reqBody, resType interface{}) error {
+	return c.call("POST", url, reqBody, resType, false)
+}
+
+// PostIdempotent is Post for calls which can safely be repeated, such as
+// reading a secret or setting a whole document, and so are retried.
+func (c *OVHClient) PostIdempotent(url string, reqBody, resType interface{}) error {
+	return c.call("POST", url, reqBody, resType, true)
+}
+
+// call sends the request, signed anew on each attempt as signatures are
+// timestamped, and retries it while the error is transient. Calls refused
+// for lack of access rights get the rule to add in their error.
+func (c *OVHClient) call(method, path string, reqBody, resType interface{}, retry bool) error {
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.send(method, path, reqBody, resType)
+		if err == nil || !retry || attempt >= c.MaxRetries || !ovherr.IsTransient(err) {
+			return c.explainForbidden(method, path, err)
+		}
+
+		wait := retryAfter
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+
+		log.Printf("[WARN] %s %s failed, retrying in %s (%d/%d): %s", method, path, wait, attempt+1, c.MaxRetries, err)
+		time.Sleep(wait)
+	}
+}
+
+// send makes a single call, returning the wait asked by the API in a
+// Retry-After header along with the error.
+func (c *OVHClient) send(method, path string, reqBody, resType interface{}) (time.Duration, error) {
+	req, err := c.Client.NewRequest(method, path, reqBody, true)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseRetryAfter(resp.Header.Get("Retry-After")), c.Client.UnmarshalResponse(resp, resType)
+}
+
+// backoff returns the wait before retrying after attempt, doubling from
+// MinBackoff up to MaxBackoff, with jitter spreading the retries of calls
+// which failed together.
+func (c *OVHClient) backoff(attempt int) time.Duration {
+	min, max := c.MinBackoff, c.MaxBackoff
+	if min <= 0 {
+		min = defaultMinBackoff
+	}
+	if max < min {
+		max = min
+	}
+
+	wait := min
+	for i := 0; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter reads a Retry-After header, either a number of seconds
+// or an http date, returning 0 when it's missing or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(v); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,107 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testRetryingClient returns the client of config retrying up to
+// maxRetries times without waiting.
+func testRetryingClient(config *Config, maxRetries int) *OVHClient {
+	c := config.OVHClient
+	c.MaxRetries = maxRetries
+	c.MinBackoff = time.Millisecond
+	c.MaxBackoff = time.Millisecond
+	return c
+}
+
+func TestOVHClientRetry(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	c := testRetryingClient(config, 3)
+	endpoint := fmt.Sprintf("/cloud/project/%s/region", ovhtest.ProjectId)
+
+	srv.FailNextRequests(3, http.StatusServiceUnavailable, "")
+	if err := c.Get(endpoint, &[]string{}); err != nil {
+		t.Errorf("expected the call to succeed after 3 retries, got %s", err)
+	}
+
+	srv.FailNextRequests(4, http.StatusTooManyRequests, "")
+	if err := c.Get(endpoint, &[]string{}); !ovherr.IsRateLimited(err) {
+		t.Errorf("expected a 429 error once retries are exhausted, got %v", err)
+	}
+
+	srv.FailNextRequests(1, http.StatusServiceUnavailable, "")
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := c.Post(fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId), params, nil); err == nil {
+		t.Errorf("expected POST not to be retried")
+	}
+
+	srv.FailNextRequests(1, http.StatusServiceUnavailable, "")
+	if err := c.PostIdempotent(fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId), params, nil); err != nil {
+		t.Errorf("expected an idempotent POST to be retried, got %s", err)
+	}
+
+	srv.FailNextRequests(1, http.StatusBadRequest, "")
+	if err := c.Get(endpoint, &[]string{}); err == nil {
+		t.Errorf("expected a 400 error not to be retried")
+	}
+}
+
+func TestOVHClientRetry_retryAfter(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	c := testRetryingClient(config, 1)
+
+	srv.FailNextRequests(1, http.StatusTooManyRequests, "1")
+	start := time.Now()
+	if err := c.Get("/me", &PartialMe{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected the call to wait for Retry-After, retried after %s", elapsed)
+	}
+}
+
+func TestOVHClientBackoff(t *testing.T) {
+	c := &OVHClient{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	cases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tc := range cases {
+		for i := 0; i < 10; i++ {
+			if wait := c.backoff(tc.attempt); wait < tc.max/2 || wait > tc.max {
+				t.Errorf("attempt %d: expected a wait between %s and %s, got %s", tc.attempt, tc.max/2, tc.max, wait)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if wait := parseRetryAfter("5"); wait != 5*time.Second {
+		t.Errorf("expected 5s, got %s", wait)
+	}
+	if wait := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); wait <= 0 || wait > time.Minute {
+		t.Errorf("expected up to a minute, got %s", wait)
+	}
+	for _, v := range []string{"", "soon", "-1"} {
+		if wait := parseRetryAfter(v); wait != 0 {
+			t.Errorf("%q: expected no wait, got %s", v, wait)
+		}
+	}
+}
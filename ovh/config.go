@@ -2,27 +2,115 @@ package ovh
 
 import (
 	"fmt"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
 	"github.com/ovh/go-ovh/ovh"
 	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Endpoints
 const (
-	OvhEU = "https://eu.api.ovh.com/1.0"
-	OvhCA = "https://ca.api.ovh.com/1.0"
+	OvhEU = ovh.OvhEU
+	OvhCA = ovh.OvhCA
 )
 
-var OVHEndpoints = map[string]string{
-	"ovh-eu": OvhEU,
-	"ovh-ca": OvhCA,
+// OVHEndpoints maps the endpoint aliases accepted by the provider to their
+// API URL. It holds every endpoint known by go-ovh.
+var OVHEndpoints = map[string]string{}
+
+func init() {
+	for k, v := range ovh.Endpoints {
+		OVHEndpoints[k] = v
+	}
+}
+
+// OVHEndpointAliases returns the sorted list of accepted endpoint aliases.
+func OVHEndpointAliases() []string {
+	aliases := make([]string, 0, len(OVHEndpoints))
+	for k := range OVHEndpoints {
+		aliases = append(aliases, k)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// resolveEndpoint returns the API URL for an endpoint alias or URL.
+// Custom URLs must use https, plain http is only allowed on loopback
+// addresses to target local mocks and recording proxies.
+func resolveEndpoint(endpoint string) (string, error) {
+	if u, ok := OVHEndpoints[endpoint]; ok {
+		return u, nil
+	}
+
+	invalid := fmt.Errorf("%q is not a valid ovh endpoint: it must be an https URL or one of %s",
+		endpoint, strings.Join(OVHEndpointAliases(), ", "))
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", invalid
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if ip := net.ParseIP(u.Hostname()); u.Hostname() != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return "", fmt.Errorf("%q is not a valid ovh endpoint: plain http is only allowed on loopback addresses", endpoint)
+		}
+	default:
+		return "", invalid
+	}
+
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+func validateOVHEndpoint(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := resolveEndpoint(v.(string)); err != nil {
+		errors = append(errors, err)
+	}
+	return
 }
 
 type Config struct {
+	// Endpoint is either an alias from OVHEndpoints or an API URL
 	Endpoint          string
 	ApplicationKey    string
 	ApplicationSecret string
 	ConsumerKey       string
-	OVHClient         *ovh.Client
+
+	// ConfigFile is an ovh.conf file to read instead of the default
+	// locations, and Profile the section to read credentials from.
+	ConfigFile string
+	Profile    string
+
+	// DefaultTimeout overrides the timeout of asynchronous operations
+	// for resources without a timeouts block.
+	DefaultTimeout time.Duration
+
+	// RegeneratePasswordOnImport makes imported public cloud users get a
+	// new password, as the API never returns the current one.
+	RegeneratePasswordOnImport bool
+
+	// MaxRetries is the number of times calls failing on transient errors
+	// are retried.
+	MaxRetries int
+
+	// CredentialExpiryWarning is how long before the consumer key expires
+	// a warning is logged.
+	CredentialExpiryWarning time.Duration
+
+	OVHClient *OVHClient
+
+	// CurrentCredential is the consumer key, as read on configuration. It's
+	// nil when the key isn't granted reading it.
+	CurrentCredential *CurrentCredential
+
+	publicCloudRegions *publicCloudRegionCache
+
+	privateNetworkWalk *pcpnWalk
 }
 
 /* type used to verify client access to ovh api
@@ -32,14 +120,19 @@ type PartialMe struct {
 }
 
 func clientDefault(c *Config) (*ovh.Client, error) {
+	endpoint, err := resolveEndpoint(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.ApplicationKey != "" && c.ApplicationSecret != "" {
-		client, err := ovh.NewClient(c.Endpoint, c.ApplicationKey, c.ApplicationSecret, c.ConsumerKey)
+		client, err := ovh.NewClient(endpoint, c.ApplicationKey, c.ApplicationSecret, c.ConsumerKey)
 		if err != nil {
 			return nil, err
 		}
 		return client, nil
 	} else {
-		client, err := ovh.NewEndpointClient(c.Endpoint)
+		client, err := ovh.NewEndpointClient(endpoint)
 		if err != nil {
 			return nil, err
 		}
@@ -48,16 +141,16 @@ func clientDefault(c *Config) (*ovh.Client, error) {
 }
 
 func (c *Config) loadAndValidate() error {
-	validEndpoint := false
+	if err := c.loadConfigFile(); err != nil {
+		return err
+	}
 
-	for k, _ := range OVHEndpoints {
-		if c.Endpoint == k {
-			validEndpoint = true
-		}
+	if c.Endpoint == "" {
+		return fmt.Errorf("endpoint must be set in the provider configuration, OVH_ENDPOINT or the [default] section of an ovh.conf file")
 	}
 
-	if !validEndpoint {
-		return fmt.Errorf("%s is not a valid ovh endpoint\n", c.Endpoint)
+	if _, err := resolveEndpoint(c.Endpoint); err != nil {
+		return err
 	}
 
 	targetClient, err := clientDefault(c)
@@ -70,14 +163,40 @@ func (c *Config) loadAndValidate() error {
 	// 	return fmt.Errorf("Error getting ovh client with CK: %q\n", err)
 	// }
 
+	client := NewOVHClient(targetClient, c.MaxRetries)
+
 	var me PartialMe
-	err = targetClient.Get("/me", &me)
+	err = client.Get("/me", &me)
 	if err != nil {
+		if ovherr.IsInvalidCredentials(err) {
+			if credential, credErr := GetCurrentCredential(client); credErr == nil {
+				if err := checkCurrentCredential(credential, time.Now(), c.CredentialExpiryWarning); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("consumer key is unknown, expired or not validated yet, create a new one: %q\n", err)
+		}
 		return fmt.Errorf("OVH client seems to be misconfigured: %q\n", err)
 	}
 
 	log.Printf("Logged in on OVH API as %s!", me.Firstname)
-	c.OVHClient = targetClient
+
+	// Consumer keys created without the GET /auth/currentCredential rule
+	// still work, their status and expiration just aren't checked.
+	credential, err := GetCurrentCredential(client)
+	if err != nil {
+		log.Printf("[WARN] couldn't check the status and expiration of the consumer key, grant it GET %s to have them checked: %s", currentCredentialPath, err)
+	} else {
+		if err := checkCurrentCredential(credential, time.Now(), c.CredentialExpiryWarning); err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] Using %s", credential)
+	}
+
+	c.OVHClient = client
+	c.CurrentCredential = credential
+	c.publicCloudRegions = newPublicCloudRegionCache()
+	c.privateNetworkWalk = newPcpnWalk()
 
 	return nil
 }
@@ -0,0 +1,110 @@
+package ovh
+
+import (
+	"fmt"
+	"gopkg.in/ini.v1"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// ovhConfigPaths lists the configuration files shared by every OVH API
+// wrapper (go-ovh, python-ovh, ...), by increasing priority.
+var ovhConfigPaths = []string{
+	"/etc/ovh.conf",
+	"~/.ovh.conf",
+	"./ovh.conf",
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home := os.Getenv("HOME")
+	if usr, err := user.Current(); err == nil {
+		home = usr.HomeDir
+	}
+	return home + path[1:]
+}
+
+// loadOVHConfigFile loads the ini configuration from path, or from the
+// default ovh.conf locations if path is empty. Default locations are all
+// optional whereas an explicit path must exist.
+func loadOVHConfigFile(path string) (*ini.File, error) {
+	if path != "" {
+		path = expandHome(path)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("couldn't read OVH configuration file: %s", err)
+		}
+		return ini.Load(path)
+	}
+
+	paths := make([]interface{}, 0, len(ovhConfigPaths))
+	for _, p := range ovhConfigPaths {
+		paths = append(paths, expandHome(p))
+	}
+	return ini.LooseLoad(paths[0], paths[1:]...)
+}
+
+func configFileValue(section *ini.Section, key string) string {
+	if section == nil || !section.HasKey(key) {
+		return ""
+	}
+	return section.Key(key).String()
+}
+
+// loadConfigFile fills the endpoint and credentials left empty by the
+// provider arguments and environment from the ovh.conf files.
+//
+// Without a profile, the endpoint comes from the [default] section and the
+// credentials from the section named after the endpoint, like go-ovh does.
+// With a profile, both come from the section named after the profile, the
+// endpoint falling back to the [default] section.
+func (c *Config) loadConfigFile() error {
+	cfg, err := loadOVHConfigFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	defaultSection, _ := cfg.GetSection("default")
+
+	var section *ini.Section
+	if c.Profile != "" {
+		section, err = cfg.GetSection(c.Profile)
+		if err != nil {
+			return fmt.Errorf("profile %q not found in OVH configuration file", c.Profile)
+		}
+
+		if c.Endpoint == "" {
+			c.Endpoint = configFileValue(section, "endpoint")
+		}
+	}
+
+	if c.Endpoint == "" {
+		c.Endpoint = configFileValue(defaultSection, "endpoint")
+	}
+
+	if c.Profile == "" && c.Endpoint != "" {
+		section, _ = cfg.GetSection(c.Endpoint)
+	}
+
+	if section == nil {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Loading OVH credentials from configuration section [%s]", section.Name())
+
+	if c.ApplicationKey == "" {
+		c.ApplicationKey = configFileValue(section, "application_key")
+	}
+	if c.ApplicationSecret == "" {
+		c.ApplicationSecret = configFileValue(section, "application_secret")
+	}
+	if c.ConsumerKey == "" {
+		c.ConsumerKey = configFileValue(section, "consumer_key")
+	}
+
+	return nil
+}
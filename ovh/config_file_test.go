@@ -0,0 +1,143 @@
+package ovh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testOVHConfigFile = `
+[default]
+endpoint=ovh-ca
+
+[ovh-ca]
+application_key=ca-key
+application_secret=ca-secret
+consumer_key=ca-consumer
+
+[ovh-eu]
+application_key=eu-key
+application_secret=eu-secret
+consumer_key=eu-consumer
+
+[staging]
+endpoint=https://proxy.example.com/1.0
+application_key=staging-key
+application_secret=staging-secret
+consumer_key=staging-consumer
+`
+
+func writeTestOVHConfigFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("couldn't write %s: %s", path, err)
+	}
+	return path
+}
+
+func TestConfigLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-provider-ovh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeTestOVHConfigFile(t, dir, "ovh.conf", testOVHConfigFile)
+
+	cases := []struct {
+		name     string
+		config   Config
+		expected Config
+	}{
+		{
+			"endpoint from default section",
+			Config{},
+			Config{Endpoint: "ovh-ca", ApplicationKey: "ca-key", ApplicationSecret: "ca-secret", ConsumerKey: "ca-consumer"},
+		},
+		{
+			"explicit endpoint",
+			Config{Endpoint: "ovh-eu"},
+			Config{Endpoint: "ovh-eu", ApplicationKey: "eu-key", ApplicationSecret: "eu-secret", ConsumerKey: "eu-consumer"},
+		},
+		{
+			"explicit credentials take precedence",
+			Config{Endpoint: "ovh-eu", ApplicationKey: "my-key", ConsumerKey: "my-consumer"},
+			Config{Endpoint: "ovh-eu", ApplicationKey: "my-key", ApplicationSecret: "eu-secret", ConsumerKey: "my-consumer"},
+		},
+		{
+			"profile",
+			Config{Profile: "staging"},
+			Config{Endpoint: "https://proxy.example.com/1.0", ApplicationKey: "staging-key", ApplicationSecret: "staging-secret", ConsumerKey: "staging-consumer"},
+		},
+		{
+			"profile with explicit endpoint",
+			Config{Profile: "staging", Endpoint: "http://127.0.0.1:8080"},
+			Config{Endpoint: "http://127.0.0.1:8080", ApplicationKey: "staging-key", ApplicationSecret: "staging-secret", ConsumerKey: "staging-consumer"},
+		},
+		{
+			"unknown endpoint section",
+			Config{Endpoint: "ovh-us"},
+			Config{Endpoint: "ovh-us"},
+		},
+	}
+
+	for _, c := range cases {
+		config := c.config
+		config.ConfigFile = path
+		if err := config.loadConfigFile(); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+			continue
+		}
+
+		config.ConfigFile = ""
+		config.Profile = ""
+		if config != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, config)
+		}
+	}
+}
+
+func TestConfigLoadConfigFile_errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-provider-ovh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeTestOVHConfigFile(t, dir, "ovh.conf", testOVHConfigFile)
+
+	config := Config{ConfigFile: path, Profile: "missing"}
+	if err := config.loadConfigFile(); err == nil {
+		t.Errorf("expected an error for a missing profile")
+	}
+
+	config = Config{ConfigFile: filepath.Join(dir, "missing.conf")}
+	if err := config.loadConfigFile(); err == nil {
+		t.Errorf("expected an error for a missing configuration file")
+	}
+}
+
+func TestConfigLoadConfigFile_defaultPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-provider-ovh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	global := writeTestOVHConfigFile(t, dir, "global.conf", testOVHConfigFile)
+	local := writeTestOVHConfigFile(t, dir, "local.conf", "[ovh-ca]\nconsumer_key=local-consumer\n")
+
+	defer func(paths []string) { ovhConfigPaths = paths }(ovhConfigPaths)
+	ovhConfigPaths = []string{global, filepath.Join(dir, "missing.conf"), local}
+
+	config := Config{}
+	if err := config.loadConfigFile(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := Config{Endpoint: "ovh-ca", ApplicationKey: "ca-key", ApplicationSecret: "ca-secret", ConsumerKey: "local-consumer"}
+	if config != expected {
+		t.Errorf("expected %+v, got %+v", expected, config)
+	}
+}
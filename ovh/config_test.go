@@ -0,0 +1,39 @@
+package ovh
+
+import (
+	"testing"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		expected string
+		valid    bool
+	}{
+		{"ovh-eu", OvhEU, true},
+		{"ovh-ca", OvhCA, true},
+		{"ovh-us", "https://api.us.ovhcloud.com/1.0", true},
+		{"kimsufi-eu", "https://eu.api.kimsufi.com/1.0", true},
+		{"soyoustart-ca", "https://ca.api.soyoustart.com/1.0", true},
+		{"https://proxy.example.com/1.0/", "https://proxy.example.com/1.0", true},
+		{"http://127.0.0.1:8080/1.0", "http://127.0.0.1:8080/1.0", true},
+		{"http://localhost:8080", "http://localhost:8080", true},
+		{"http://proxy.example.com/1.0", "", false},
+		{"ftp://proxy.example.com/1.0", "", false},
+		{"ovh-mars", "", false},
+		{"", "", false},
+	}
+
+	for _, c := range cases {
+		u, err := resolveEndpoint(c.endpoint)
+		if c.valid && err != nil {
+			t.Errorf("%q: unexpected error: %s", c.endpoint, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%q: expected an error, got %q", c.endpoint, u)
+		}
+		if u != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.endpoint, c.expected, u)
+		}
+	}
+}
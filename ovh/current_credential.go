@@ -0,0 +1,80 @@
+package ovh
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// currentCredentialPath is the endpoint describing the consumer key in use.
+const currentCredentialPath = "/auth/currentCredential"
+
+// CurrentCredential is the consumer key in use, as described by
+// /auth/currentCredential.
+type CurrentCredential struct {
+	CredentialId  int          `json:"credentialId"`
+	ApplicationId int          `json:"applicationId"`
+	Status        string       `json:"status"`
+	Creation      string       `json:"creation"`
+	Expiration    *string      `json:"expiration"`
+	LastUse       *string      `json:"lastUse"`
+	OvhSupport    bool         `json:"ovhSupport"`
+	Rules         []AccessRule `json:"rules"`
+}
+
+func (c *CurrentCredential) String() string {
+	return fmt.Sprintf("CurrentCredential[Id: %d, Status: %s, Rules: %s]", c.CredentialId, c.Status, c.Rules)
+}
+
+// GetCurrentCredential reads the consumer key in use.
+func GetCurrentCredential(c *OVHClient) (*CurrentCredential, error) {
+	r := &CurrentCredential{}
+	err := c.Get(currentCredentialPath, r)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] calling %s:\n\t %q", currentCredentialPath, err)
+	}
+	return r, nil
+}
+
+// ExpiresAt returns the expiration of the consumer key, false when it never
+// expires.
+func (c *CurrentCredential) ExpiresAt() (time.Time, bool, error) {
+	if c.Expiration == nil || *c.Expiration == "" {
+		return time.Time{}, false, nil
+	}
+
+	expiration, err := time.Parse(time.RFC3339, *c.Expiration)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid expiration %q of consumer key %d: %s", *c.Expiration, c.CredentialId, err)
+	}
+	return expiration, true, nil
+}
+
+// checkCurrentCredential fails for consumer keys which can't be used, being
+// expired, refused or pending validation, and warns when the key expires
+// within window.
+func checkCurrentCredential(c *CurrentCredential, now time.Time, window time.Duration) error {
+	switch c.Status {
+	case "pendingValidation":
+		return fmt.Errorf("consumer key %d is pending validation: visit the validation URL returned along with it, or create a new one", c.CredentialId)
+	case "refused":
+		return fmt.Errorf("consumer key %d was refused at validation: create a new one", c.CredentialId)
+	case "expired":
+		return fmt.Errorf("consumer key %d is expired: create a new one", c.CredentialId)
+	}
+
+	expiration, expires, err := c.ExpiresAt()
+	if err != nil || !expires {
+		return err
+	}
+
+	if !now.Before(expiration) {
+		return fmt.Errorf("consumer key %d expired on %s: create a new one", c.CredentialId, expiration.Format(time.RFC3339))
+	}
+
+	if left := expiration.Sub(now); left <= window {
+		log.Printf("[WARN] consumer key %d expires on %s, in %s: create a new one before then", c.CredentialId, expiration.Format(time.RFC3339), left.Round(time.Minute))
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package ovh
+
+import (
+	"bytes"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCurrentCredential(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiration := func(d time.Duration) *string {
+		v := now.Add(d).Format(time.RFC3339)
+		return &v
+	}
+	invalid := "tomorrow"
+
+	cases := []struct {
+		name       string
+		credential CurrentCredential
+		err        string
+		warn       bool
+	}{
+		{"validated", CurrentCredential{Status: "validated"}, "", false},
+		{"expires later", CurrentCredential{Status: "validated", Expiration: expiration(30 * 24 * time.Hour)}, "", false},
+		{"expires soon", CurrentCredential{Status: "validated", Expiration: expiration(time.Hour)}, "", true},
+		{"pending validation", CurrentCredential{Status: "pendingValidation"}, "pending validation", false},
+		{"refused", CurrentCredential{Status: "refused"}, "refused", false},
+		{"expired status", CurrentCredential{Status: "expired"}, "is expired", false},
+		{"past expiration", CurrentCredential{Status: "validated", Expiration: expiration(-time.Minute)}, "expired on", false},
+		{"invalid expiration", CurrentCredential{Status: "validated", Expiration: &invalid}, "invalid expiration", false},
+	}
+
+	for _, c := range cases {
+		var err error
+		logs := testCaptureLog(func() {
+			err = checkCurrentCredential(&c.credential, now, 7*24*time.Hour)
+		})
+		if c.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.name, c.err, err)
+		}
+		if warned := strings.Contains(logs, "[WARN]"); warned != c.warn {
+			t.Errorf("%s: expected a warning: %v, got logs %q", c.name, c.warn, logs)
+		}
+	}
+}
+
+// testCaptureLog returns what f logs.
+func testCaptureLog(f func()) string {
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	defer log.SetOutput(os.Stdout)
+
+	f()
+	return b.String()
+}
+
+func TestLoadAndValidate_currentCredential(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(*ovhtest.Server)
+		err   string
+	}{
+		{"pending validation", func(s *ovhtest.Server) { s.SetCredentialStatus("pendingValidation") }, "pending validation"},
+		{"expired status", func(s *ovhtest.Server) { s.SetCredentialStatus("expired") }, "is expired"},
+		{"past expiration", func(s *ovhtest.Server) { s.SetCredentialExpiration(time.Now().Add(-time.Hour)) }, "expired on"},
+	}
+
+	for _, c := range cases {
+		srv := ovhtest.NewServer()
+		c.setup(srv)
+
+		config := &Config{
+			Endpoint:          srv.URL,
+			ApplicationKey:    srv.ApplicationKey,
+			ApplicationSecret: srv.ApplicationSecret,
+			ConsumerKey:       srv.ConsumerKey,
+		}
+
+		err := config.loadAndValidate()
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.name, c.err, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestLoadAndValidate_expiringCredential(t *testing.T) {
+	srv := ovhtest.NewServer()
+	defer srv.Close()
+	srv.SetCredentialExpiration(time.Now().Add(time.Hour))
+
+	config := &Config{
+		Endpoint:                srv.URL,
+		ApplicationKey:          srv.ApplicationKey,
+		ApplicationSecret:       srv.ApplicationSecret,
+		ConsumerKey:             srv.ConsumerKey,
+		CredentialExpiryWarning: 24 * time.Hour,
+	}
+
+	if err := config.loadAndValidate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.CurrentCredential == nil || config.CurrentCredential.Expiration == nil {
+		t.Errorf("expected the expiring consumer key to be kept, got %v", config.CurrentCredential)
+	}
+}
+
+func TestLoadAndValidate_currentCredentialNotGranted(t *testing.T) {
+	srv := ovhtest.NewServer()
+	defer srv.Close()
+	srv.SetAccessRules(ovhtest.AccessRule{Method: "GET", Path: "/me"})
+
+	config := &Config{
+		Endpoint:          srv.URL,
+		ApplicationKey:    srv.ApplicationKey,
+		ApplicationSecret: srv.ApplicationSecret,
+		ConsumerKey:       srv.ConsumerKey,
+	}
+
+	var err error
+	logs := testCaptureLog(func() { err = config.loadAndValidate() })
+	if err != nil {
+		t.Fatalf("unexpected error for a consumer key not granted %s: %s", currentCredentialPath, err)
+	}
+	if config.CurrentCredential != nil {
+		t.Errorf("expected no current credential, got %s", config.CurrentCredential)
+	}
+	if !strings.Contains(logs, "[WARN]") || !strings.Contains(logs, currentCredentialPath) {
+		t.Errorf("expected a warning naming %s, got logs %q", currentCredentialPath, logs)
+	}
+}
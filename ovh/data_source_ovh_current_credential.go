@@ -0,0 +1,121 @@
+package ovh
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"strconv"
+	"time"
+)
+
+var dataSourceCurrentCredentialAccessRules = []AccessRule{
+	{"GET", currentCredentialPath},
+}
+
+func dataSourceCurrentCredential() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceCurrentCredentialRead,
+
+		Schema: map[string]*schema.Schema{
+			"credential_id": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"application_id": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"creation": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expiration": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"never_expires": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"expires_in": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"last_use": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ovh_support": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"rules": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"method": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"path": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceCurrentCredentialRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	credential, err := GetCurrentCredential(config.OVHClient)
+	if err != nil {
+		return err
+	}
+
+	expiration, expires, err := credential.ExpiresAt()
+	if err != nil {
+		return err
+	}
+
+	d.SetId(strconv.Itoa(credential.CredentialId))
+	d.Set("credential_id", credential.CredentialId)
+	d.Set("application_id", credential.ApplicationId)
+	d.Set("status", credential.Status)
+	d.Set("creation", credential.Creation)
+	d.Set("ovh_support", credential.OvhSupport)
+	d.Set("never_expires", !expires)
+
+	if expires {
+		d.Set("expiration", expiration.Format(time.RFC3339))
+		d.Set("expires_in", int(time.Until(expiration).Seconds()))
+	} else {
+		d.Set("expiration", "")
+		d.Set("expires_in", 0)
+	}
+
+	if credential.LastUse != nil {
+		d.Set("last_use", *credential.LastUse)
+	}
+
+	rules := make([]map[string]interface{}, 0, len(credential.Rules))
+	for _, rule := range credential.Rules {
+		rules = append(rules, map[string]interface{}{
+			"method": rule.Method,
+			"path":   rule.Path,
+		})
+	}
+	if err := d.Set("rules", rules); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Read %s", credential)
+	return nil
+}
@@ -0,0 +1,60 @@
+package ovh
+
+import (
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"testing"
+	"time"
+)
+
+const testAccCurrentCredentialDataSourceConfig = `
+data "ovh_current_credential" "credential" {}
+`
+
+func TestAccCurrentCredentialDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCurrentCredentialDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.ovh_current_credential.credential", "credential_id"),
+					resource.TestCheckResourceAttr("data.ovh_current_credential.credential", "status", "validated"),
+					resource.TestCheckResourceAttrSet("data.ovh_current_credential.credential", "rules.#"),
+				),
+			},
+		},
+	})
+}
+
+func TestCurrentCredentialDataSourceRead(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceCurrentCredential().Schema, map[string]interface{}{})
+
+	if err := dataSourceCurrentCredentialRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() == "" || d.Get("status").(string) != "validated" {
+		t.Errorf("expected a validated consumer key, got id %q and status %q", d.Id(), d.Get("status"))
+	}
+	if !d.Get("never_expires").(bool) || d.Get("expiration").(string) != "" {
+		t.Errorf("expected a consumer key never expiring, got expiration %q", d.Get("expiration"))
+	}
+	if d.Get("rules.#").(int) == 0 {
+		t.Errorf("expected the access rules to be set")
+	}
+
+	srv.SetCredentialExpiration(time.Now().Add(48 * time.Hour))
+	if err := dataSourceCurrentCredentialRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Get("never_expires").(bool) || d.Get("expiration").(string) == "" {
+		t.Errorf("expected the expiration to be set")
+	}
+	if in := d.Get("expires_in").(int); in <= 47*3600 || in > 48*3600 {
+		t.Errorf("expected the consumer key to expire in about 48h, got %ds", in)
+	}
+}
@@ -0,0 +1,88 @@
+package ovh
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"sort"
+)
+
+var dataSourcePublicCloudUserRolesAccessRules = []AccessRule{
+	{"GET", "/cloud/project/*/role"},
+}
+
+func dataSourcePublicCloudUserRoles() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePublicCloudUserRolesRead,
+
+		Schema: map[string]*schema.Schema{
+			"project_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OVH_PROJECT_ID", ""),
+			},
+			"names": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"roles": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"permissions": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePublicCloudUserRolesRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	projectId := d.Get("project_id").(string)
+
+	roles, err := GetPublicCloudRoles(config, projectId)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	names := make([]string, 0, len(roles))
+	attributes := make([]map[string]interface{}, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+		attributes = append(attributes, map[string]interface{}{
+			"id":          role.Id,
+			"name":        role.Name,
+			"description": role.Description,
+			"permissions": role.Permissions,
+		})
+	}
+
+	d.SetId(projectId)
+	d.Set("names", names)
+	if err := d.Set("roles", attributes); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Read public cloud user roles of project %s: %v", projectId, names)
+	return nil
+}
@@ -0,0 +1,62 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"os"
+	"testing"
+)
+
+const testAccPublicCloudUserRolesDataSourceConfigTemplate = `
+data "ovh_publiccloud_user_roles" "roles" {
+  project_id = "%s"
+}
+`
+
+func testAccPublicCloudUserRolesDataSourceConfig() string {
+	return fmt.Sprintf(testAccPublicCloudUserRolesDataSourceConfigTemplate, os.Getenv("OVH_PUBLIC_CLOUD"))
+}
+
+func TestAccPublicCloudUserRolesDataSource_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccCheckPublicCloudUserPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublicCloudUserRolesDataSourceConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.ovh_publiccloud_user_roles.roles", "names.#"),
+					resource.TestCheckResourceAttrSet("data.ovh_publiccloud_user_roles.roles", "roles.0.id"),
+				),
+			},
+		},
+	})
+}
+
+func TestPublicCloudUserRolesDataSourceRead(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourcePublicCloudUserRoles().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+	})
+
+	if err := dataSourcePublicCloudUserRolesRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != ovhtest.ProjectId {
+		t.Errorf("expected id %s, got %s", ovhtest.ProjectId, d.Id())
+	}
+	if name := d.Get("names.0").(string); name != "admin" {
+		t.Errorf("expected roles to be sorted by name, got %s first", name)
+	}
+	if id := d.Get("roles.0.id").(string); id == "" {
+		t.Errorf("expected the role ids to be set")
+	}
+	if d.Get("roles.#").(int) != d.Get("names.#").(int) {
+		t.Errorf("expected as many roles as names, got %d and %d", d.Get("roles.#"), d.Get("names.#"))
+	}
+}
@@ -0,0 +1,185 @@
+// Package ovherr classifies the errors returned by the go-ovh client, be
+// they OVH API errors or not, so that callers never have to type assert
+// them.
+package ovherr
+
+import (
+	"errors"
+	"github.com/ovh/go-ovh/ovh"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// Class is the kind of failure an error stands for.
+type Class int
+
+const (
+	// Unknown is the class of nil errors and of errors matching no other
+	// class.
+	Unknown Class = iota
+	// NotFound is an API 404.
+	NotFound
+	// Conflict is an API 409, usually an object in a state forbidding the
+	// call.
+	Conflict
+	// Forbidden is an API 403 for a call the credential isn't granted.
+	Forbidden
+	// InvalidCredentials is an API 401, or a 403 for an unknown, expired
+	// or not validated credential or application key.
+	InvalidCredentials
+	// RateLimited is an API 429.
+	RateLimited
+	// Server is an API 5xx.
+	Server
+	// TransientNetwork is a timeout or a connection refused or reset
+	// before getting an API response.
+	TransientNetwork
+)
+
+var classNames = map[Class]string{
+	Unknown:            "unknown",
+	NotFound:           "not found",
+	Conflict:           "conflict",
+	Forbidden:          "forbidden",
+	InvalidCredentials: "invalid credentials",
+	RateLimited:        "rate limited",
+	Server:             "server error",
+	TransientNetwork:   "transient network error",
+}
+
+func (c Class) String() string {
+	if name, ok := classNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// invalidCredentialsMessages are parts of the messages the API sends along
+// with a 403 when the credential itself, not its rights, is at fault.
+var invalidCredentialsMessages = []string{
+	"credential is not valid",
+	"credential does not exist",
+	"invalid credential",
+	"invalid application key",
+	"consumer key is expired",
+}
+
+// APIError returns the OVH API error err is or wraps.
+func APIError(err error) (*ovh.APIError, bool) {
+	var apiErr *ovh.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	var apiErrValue ovh.APIError
+	if errors.As(err, &apiErrValue) {
+		return &apiErrValue, true
+	}
+
+	return nil, false
+}
+
+// Classify returns the class of err.
+func Classify(err error) Class {
+	if err == nil {
+		return Unknown
+	}
+
+	if apiErr, ok := APIError(err); ok {
+		return classifyAPIError(apiErr)
+	}
+
+	if isTransientNetworkError(err) {
+		return TransientNetwork
+	}
+
+	return Unknown
+}
+
+func classifyAPIError(err *ovh.APIError) Class {
+	switch {
+	case err.Code == http.StatusNotFound:
+		return NotFound
+	case err.Code == http.StatusConflict:
+		return Conflict
+	case err.Code == http.StatusUnauthorized:
+		return InvalidCredentials
+	case err.Code == http.StatusForbidden:
+		message := strings.ToLower(err.Message)
+		for _, m := range invalidCredentialsMessages {
+			if strings.Contains(message, m) {
+				return InvalidCredentials
+			}
+		}
+		return Forbidden
+	case err.Code == http.StatusTooManyRequests:
+		return RateLimited
+	case err.Code >= 500:
+		return Server
+	}
+
+	return Unknown
+}
+
+func isTransientNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// IsNotFound tells if err is an API 404.
+func IsNotFound(err error) bool {
+	return Classify(err) == NotFound
+}
+
+// IsConflict tells if err is an API 409.
+func IsConflict(err error) bool {
+	return Classify(err) == Conflict
+}
+
+// IsForbidden tells if err is an API 403 for a call the credential isn't
+// granted.
+func IsForbidden(err error) bool {
+	return Classify(err) == Forbidden
+}
+
+// IsInvalidCredentials tells if err is due to an unknown, expired or not
+// validated credential.
+func IsInvalidCredentials(err error) bool {
+	return Classify(err) == InvalidCredentials
+}
+
+// IsRateLimited tells if err is an API 429.
+func IsRateLimited(err error) bool {
+	return Classify(err) == RateLimited
+}
+
+// IsTransientNetwork tells if err is a timeout or a connection refused or
+// reset.
+func IsTransientNetwork(err error) bool {
+	return Classify(err) == TransientNetwork
+}
+
+// IsTransient tells if err is worth retrying: rate limiting, server errors
+// and transient network errors.
+func IsTransient(err error) bool {
+	switch Classify(err) {
+	case RateLimited, Server, TransientNetwork:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package ovherr
+
+import (
+	"context"
+	"fmt"
+	"github.com/ovh/go-ovh/ovh"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name  string
+		err   error
+		class Class
+	}{
+		{"nil", nil, Unknown},
+		{"404", &ovh.APIError{Code: 404, Message: "This service does not exist"}, NotFound},
+		{"404 value", ovh.APIError{Code: 404}, NotFound},
+		{"wrapped 404", fmt.Errorf("calling /me: %w", &ovh.APIError{Code: 404}), NotFound},
+		{"409", &ovh.APIError{Code: 409, Message: "Pending task"}, Conflict},
+		{"403", &ovh.APIError{Code: 403, Message: "This call has not been granted"}, Forbidden},
+		{"403 invalid credential", &ovh.APIError{Code: 403, Message: "This credential is not valid"}, InvalidCredentials},
+		{"403 unknown credential", &ovh.APIError{Code: 403, Message: "This credential does not exist"}, InvalidCredentials},
+		{"403 application key", &ovh.APIError{Code: 403, Message: "Invalid application key"}, InvalidCredentials},
+		{"401", &ovh.APIError{Code: 401, Message: "You must login first"}, InvalidCredentials},
+		{"429", &ovh.APIError{Code: 429}, RateLimited},
+		{"500", &ovh.APIError{Code: 500}, Server},
+		{"503", &ovh.APIError{Code: 503}, Server},
+		{"400", &ovh.APIError{Code: 400, Message: "Invalid parameter"}, Unknown},
+		{"timeout", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: timeoutError{}}, TransientNetwork},
+		{"deadline", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: context.DeadlineExceeded}, TransientNetwork},
+		{"reset", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, TransientNetwork},
+		{"refused", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, TransientNetwork},
+		{"eof", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: io.EOF}, TransientNetwork},
+		{"temporary dns", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}}, TransientNetwork},
+		{"unknown host", &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: &net.DNSError{Err: "no such host", Name: "eu.api.ovh.com"}}, Unknown},
+		{"plain error", fmt.Errorf("invalid character 'x'"), Unknown},
+	}
+
+	for _, c := range cases {
+		if class := Classify(c.err); class != c.class {
+			t.Errorf("%s: expected %s, got %s", c.name, c.class, class)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, err := range []error{&ovh.APIError{Code: 429}, &ovh.APIError{Code: 502}, io.ErrUnexpectedEOF} {
+		if !IsTransient(err) {
+			t.Errorf("%v: expected a transient error", err)
+		}
+	}
+
+	for _, err := range []error{nil, &ovh.APIError{Code: 404}, &ovh.APIError{Code: 403}, fmt.Errorf("boom")} {
+		if IsTransient(err) {
+			t.Errorf("%v: expected a permanent error", err)
+		}
+	}
+}
+
+func TestPredicates_nonAPIErrors(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://eu.api.ovh.com/1.0/me", Err: &net.DNSError{Err: "no such host"}}
+
+	for name, is := range map[string]func(error) bool{
+		"IsNotFound":           IsNotFound,
+		"IsConflict":           IsConflict,
+		"IsForbidden":          IsForbidden,
+		"IsInvalidCredentials": IsInvalidCredentials,
+		"IsRateLimited":        IsRateLimited,
+		"IsTransientNetwork":   IsTransientNetwork,
+	} {
+		if is(err) {
+			t.Errorf("%s: unexpected match for %v", name, err)
+		}
+	}
+
+	if _, ok := APIError(err); ok {
+		t.Errorf("expected no API error in %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package ovhtest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AccessRule grants a consumer key the calls of Method on the paths
+// matching Path, where "*" matches any sequence of characters.
+type AccessRule struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// allAccessRules are the rules the fake consumer key is granted by default.
+var allAccessRules = []AccessRule{
+	{"GET", "/*"},
+	{"POST", "/*"},
+	{"PUT", "/*"},
+	{"DELETE", "/*"},
+}
+
+type credential struct {
+	CredentialId  int          `json:"credentialId"`
+	ApplicationId int          `json:"applicationId"`
+	Status        string       `json:"status"`
+	Creation      string       `json:"creation"`
+	Expiration    *string      `json:"expiration"`
+	LastUse       string       `json:"lastUse"`
+	OvhSupport    bool         `json:"ovhSupport"`
+	Rules         []AccessRule `json:"rules"`
+}
+
+func newCredential() *credential {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return &credential{
+		CredentialId:  42,
+		ApplicationId: 4242,
+		Status:        "validated",
+		Creation:      now,
+		LastUse:       now,
+		Rules:         allAccessRules,
+	}
+}
+
+// SetAccessRules replaces the access rules of the fake consumer key, calls
+// matching none of them being refused with a 403.
+func (s *Server) SetAccessRules(rules ...AccessRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credential.Rules = rules
+}
+
+// SetCredentialStatus sets the status of the fake consumer key, any other
+// than "validated" getting every call refused but reading the current
+// credential.
+func (s *Server) SetCredentialStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.credential.Status = status
+}
+
+// SetCredentialExpiration makes the fake consumer key expire at expiration,
+// calls being refused once it's past.
+func (s *Server) SetCredentialExpiration(expiration time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := expiration.UTC().Format(time.RFC3339)
+	s.credential.Expiration = &v
+}
+
+// credentialValid tells if the fake consumer key can be used.
+func (s *Server) credentialValid() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.credential.Status != "validated" {
+		return false
+	}
+	if s.credential.Expiration != nil {
+		expiration, err := time.Parse(time.RFC3339, *s.credential.Expiration)
+		return err == nil && time.Now().Before(expiration)
+	}
+	return true
+}
+
+// granted tells if the consumer key is allowed to call method on path.
+func (s *Server) granted(method, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rule := range s.credential.Rules {
+		if rule.Method != method {
+			continue
+		}
+		pattern := "^" + strings.Replace(regexp.QuoteMeta(rule.Path), `\*`, ".*", -1) + "$"
+		if regexp.MustCompile(pattern).MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getCurrentCredential(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, s.credential)
+}
+
+// postCredential answers the unauthenticated consumer key requests with a
+// key pending validation.
+func (s *Server) postCredential(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Ovh-Application") != s.ApplicationKey {
+		writeError(w, http.StatusForbidden, "Invalid application key")
+		return
+	}
+
+	req := struct {
+		AccessRules []AccessRule `json:"accessRules"`
+		Redirection string       `json:"redirection"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.AccessRules) == 0 {
+		writeError(w, http.StatusBadRequest, "Missing accessRules")
+		return
+	}
+
+	s.mu.Lock()
+	id := s.newId()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"consumerKey":   fmt.Sprintf("fake-pending-consumer-key-%d", id),
+		"state":         "pendingValidation",
+		"validationUrl": fmt.Sprintf("%s/auth/sso/%d", s.URL, id),
+	})
+}
@@ -0,0 +1,947 @@
+package ovhtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type region struct {
+	Name               string `json:"name"`
+	ContinentCode      string `json:"continentCode"`
+	DatacenterLocation string `json:"datacenterLocation"`
+	Status             string `json:"status"`
+}
+
+// regions are the regions available to every fake project.
+var regions = []region{
+	{"BHS1", "NA", "BHS", "UP"},
+	{"BHS5", "NA", "BHS", "UP"},
+	{"DE1", "EU", "DE", "UP"},
+	{"GRA1", "EU", "GRA", "UP"},
+	{"GRA7", "EU", "GRA", "UP"},
+	{"SBG5", "EU", "SBG", "UP"},
+	{"UK1", "EU", "UK", "UP"},
+	{"WAW1", "EU", "WAW", "UP"},
+}
+
+type role struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// roles are the user roles available to every fake project.
+var roles = []role{
+	{"role-0001", "admin", "Administrator", []string{"*"}},
+	{"role-0002", "authentication", "Authentication", []string{"identity:*"}},
+	{"role-0003", "compute_operator", "Compute Operator", []string{"compute:*"}},
+	{"role-0004", "network_operator", "Network Operator", []string{"network:*"}},
+	{"role-0005", "network_security_operator", "Network Security Operator", []string{"network:security_group:*"}},
+	{"role-0006", "objectstore_operator", "ObjectStore Operator", []string{"objectstore:*"}},
+	{"role-0007", "volume_operator", "Volume Operator", []string{"volume:*"}},
+}
+
+func findRole(match func(r role) bool) *role {
+	for _, r := range roles {
+		if match(r) {
+			return &r
+		}
+	}
+	return nil
+}
+
+type project struct {
+	id          string
+	networks    map[string]*privateNetwork
+	users       map[string]*user
+	failoverIps map[string]*failoverIp
+	instances   map[string]*instance
+}
+
+func newProject(id string) *project {
+	return &project{
+		id:          id,
+		networks:    map[string]*privateNetwork{},
+		users:       map[string]*user{},
+		failoverIps: map[string]*failoverIp{},
+		instances:   map[string]*instance{},
+	}
+}
+
+type privateNetworkRegion struct {
+	Status string `json:"status"`
+	Region string `json:"region"`
+}
+
+type privateNetwork struct {
+	Id      string                  `json:"id"`
+	Status  string                  `json:"status"`
+	VlanId  int                     `json:"vlanId"`
+	Name    string                  `json:"name"`
+	Type    string                  `json:"type"`
+	Regions []*privateNetworkRegion `json:"regions"`
+
+	subnets map[string]*subnet
+}
+
+type ipPool struct {
+	Network string `json:"network"`
+	Region  string `json:"region"`
+	Dhcp    bool   `json:"dhcp"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type subnet struct {
+	Id        string    `json:"id"`
+	GatewayIp *string   `json:"gatewayIp"`
+	Cidr      string    `json:"cidr"`
+	IPPools   []*ipPool `json:"ipPools"`
+}
+
+type user struct {
+	Id           int    `json:"id"`
+	Username     string `json:"username"`
+	Status       string `json:"status"`
+	Description  string `json:"description"`
+	Password     string `json:"password,omitempty"`
+	CreationDate string `json:"creationDate"`
+	Roles        []role `json:"roles"`
+
+	s3Credentials map[string]*s3Credential
+	policy        string
+}
+
+type s3Credential struct {
+	Access   string `json:"access"`
+	Secret   string `json:"secret,omitempty"`
+	UserId   string `json:"userId"`
+	TenantId string `json:"tenantId"`
+}
+
+type failoverIp struct {
+	Id            string `json:"id"`
+	IP            string `json:"ip"`
+	Block         string `json:"block"`
+	ContinentCode string `json:"continentCode"`
+	GeoLocation   string `json:"geoloc"`
+	Status        string `json:"status"`
+	SubType       string `json:"subType"`
+	RoutedTo      string `json:"routedTo"`
+	Progress      int    `json:"progress"`
+}
+
+type instance struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Status string `json:"status"`
+}
+
+func notFound(w http.ResponseWriter, kind, id string) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("The requested object (%s = %s) does not exist", kind, id))
+}
+
+func (s *Server) findProject(w http.ResponseWriter, id string) *project {
+	p, ok := s.projects[id]
+	if !ok {
+		notFound(w, "serviceName", id)
+		return nil
+	}
+	return p
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"project_id":  p.id,
+		"status":      "ok",
+		"description": "fake public cloud project",
+	})
+}
+
+func (s *Server) listRegions(w http.ResponseWriter, r *http.Request, params []string) {
+	if p := s.findProject(w, params[0]); p == nil {
+		return
+	}
+
+	names := []string{}
+	for _, region := range regions {
+		names = append(names, region.Name)
+	}
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *Server) getRegion(w http.ResponseWriter, r *http.Request, params []string) {
+	if p := s.findProject(w, params[0]); p == nil {
+		return
+	}
+
+	for _, region := range regions {
+		if region.Name == params[1] {
+			writeJSON(w, http.StatusOK, region)
+			return
+		}
+	}
+
+	notFound(w, "regionName", params[1])
+}
+
+//
+// Private networks
+//
+
+func (s *Server) findPrivateNetwork(w http.ResponseWriter, params []string) (*project, *privateNetwork) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return nil, nil
+	}
+
+	n, ok := p.networks[params[1]]
+	if !ok {
+		notFound(w, "networkId", params[1])
+		return nil, nil
+	}
+	return p, n
+}
+
+func (s *Server) listPrivateNetworks(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	networks := []*privateNetwork{}
+	for _, n := range p.networks {
+		networks = append(networks, n)
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Id < networks[j].Id })
+
+	writeJSON(w, http.StatusOK, networks)
+}
+
+func (s *Server) postPrivateNetwork(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	req := struct {
+		VlanId  int      `json:"vlanId"`
+		Name    string   `json:"name"`
+		Regions []string `json:"regions"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, n := range p.networks {
+		if n.VlanId == req.VlanId {
+			writeError(w, http.StatusConflict, fmt.Sprintf("A private network with vlanId %d already exists", req.VlanId))
+			return
+		}
+	}
+
+	n := &privateNetwork{
+		Id:      fmt.Sprintf("pn-%d_%d", s.newId(), req.VlanId),
+		Status:  "BUILDING",
+		VlanId:  req.VlanId,
+		Name:    req.Name,
+		Type:    "private",
+		Regions: []*privateNetworkRegion{},
+		subnets: map[string]*subnet{},
+	}
+	for _, region := range req.Regions {
+		n.Regions = append(n.Regions, &privateNetworkRegion{Status: "BUILDING", Region: region})
+	}
+	p.networks[n.Id] = n
+
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) getPrivateNetwork(w http.ResponseWriter, r *http.Request, params []string) {
+	p, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	// regions are only built once the network itself is ACTIVE, i.e. one
+	// read after it on creation
+	wasActive := n.Status == "ACTIVE"
+
+	switch n.Status {
+	case "BUILDING":
+		n.Status = "ACTIVE"
+	case "DELETING":
+		delete(p.networks, n.Id)
+		notFound(w, "networkId", n.Id)
+		return
+	}
+
+	for _, region := range n.Regions {
+		if region.Status == "BUILDING" && wasActive {
+			region.Status = "ACTIVE"
+			if s.failingRegions[region.Region] {
+				region.Status = "ERROR"
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) postPrivateNetworkRegion(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	req := struct {
+		Region string `json:"region"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	known := false
+	for _, region := range regions {
+		if region.Name == req.Region {
+			known = true
+		}
+	}
+	if !known {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid region %s", req.Region))
+		return
+	}
+
+	for _, region := range n.Regions {
+		if region.Region == req.Region {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Private network %s is already enabled in region %s", n.Id, req.Region))
+			return
+		}
+	}
+
+	n.Regions = append(n.Regions, &privateNetworkRegion{Status: "BUILDING", Region: req.Region})
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) putPrivateNetwork(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	req := struct {
+		Name string `json:"name"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	n.Name = req.Name
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) deletePrivateNetwork(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	if len(n.subnets) > 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Private network %s still has subnets", n.Id))
+		return
+	}
+
+	n.Status = "DELETING"
+	writeJSON(w, http.StatusOK, nil)
+}
+
+//
+// Subnets
+//
+
+func (s *Server) listSubnets(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	subnets := []*subnet{}
+	for _, sn := range n.subnets {
+		subnets = append(subnets, sn)
+	}
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i].Id < subnets[j].Id })
+
+	writeJSON(w, http.StatusOK, subnets)
+}
+
+func (s *Server) postSubnet(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	req := struct {
+		Dhcp      bool   `json:"dhcp"`
+		NoGateway bool   `json:"noGateway"`
+		Start     string `json:"start"`
+		End       string `json:"end"`
+		Network   string `json:"network"`
+		Region    string `json:"region"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, ipnet, err := net.ParseCIDR(req.Network)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid network %s", req.Network))
+		return
+	}
+
+	var networkRegion *privateNetworkRegion
+	for _, region := range n.Regions {
+		if region.Region == req.Region {
+			networkRegion = region
+		}
+	}
+	if networkRegion == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Region %s is not enabled on private network %s", req.Region, n.Id))
+		return
+	}
+	if networkRegion.Status != "ACTIVE" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Private network %s is not ACTIVE in region %s", n.Id, req.Region))
+		return
+	}
+
+	sn := &subnet{
+		Id:   fmt.Sprintf("subnet-%d", s.newId()),
+		Cidr: ipnet.String(),
+		IPPools: []*ipPool{{
+			Network: req.Network,
+			Region:  req.Region,
+			Dhcp:    req.Dhcp,
+			Start:   req.Start,
+			End:     req.End,
+		}},
+	}
+	if !req.NoGateway {
+		gw := make(net.IP, len(ipnet.IP))
+		copy(gw, ipnet.IP)
+		gw[len(gw)-1]++
+		gateway := gw.String()
+		sn.GatewayIp = &gateway
+	}
+	n.subnets[sn.Id] = sn
+
+	writeJSON(w, http.StatusOK, sn)
+}
+
+func (s *Server) deleteSubnet(w http.ResponseWriter, r *http.Request, params []string) {
+	_, n := s.findPrivateNetwork(w, params)
+	if n == nil {
+		return
+	}
+
+	if _, ok := n.subnets[params[2]]; !ok {
+		notFound(w, "subnetId", params[2])
+		return
+	}
+
+	delete(n.subnets, params[2])
+	writeJSON(w, http.StatusOK, nil)
+}
+
+//
+// Users
+//
+
+func (s *Server) findUser(w http.ResponseWriter, params []string) (*project, *user) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return nil, nil
+	}
+
+	u, ok := p.users[params[1]]
+	if !ok {
+		notFound(w, "userId", params[1])
+		return nil, nil
+	}
+	return p, u
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	users := []user{}
+	for _, u := range p.users {
+		users = append(users, *u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) postUser(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	req := struct {
+		Description string   `json:"description"`
+		Role        string   `json:"role"`
+		Roles       []string `json:"roles"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	names := req.Roles
+	if req.Role != "" {
+		names = append(names, req.Role)
+	}
+	if len(names) == 0 {
+		names = []string{"admin"}
+	}
+
+	userRoles := []role{}
+	for _, name := range names {
+		ro := findRole(func(r role) bool { return r.Name == name })
+		if ro == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid role %s", name))
+			return
+		}
+		userRoles = append(userRoles, *ro)
+	}
+
+	id := s.newId()
+	u := &user{
+		Id:           id,
+		Username:     fmt.Sprintf("user-%d", id),
+		Status:       "creating",
+		Description:  req.Description,
+		CreationDate: time.Now().UTC().Format(time.RFC3339),
+		Roles:        userRoles,
+
+		s3Credentials: map[string]*s3Credential{},
+	}
+	p.users[strconv.Itoa(id)] = u
+
+	created := *u
+	created.Password = fmt.Sprintf("fake-password-%d", s.newId())
+	writeJSON(w, http.StatusOK, created)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, params []string) {
+	p, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	switch u.Status {
+	case "creating", "updating":
+		u.Status = "ok"
+	case "deleting":
+		delete(p.users, params[1])
+		notFound(w, "userId", params[1])
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	u.Status = "deleting"
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) regenerateUserPassword(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	u.Status = "updating"
+
+	updated := *u
+	updated.Password = fmt.Sprintf("fake-password-%d", s.newId())
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) listRoles(w http.ResponseWriter, r *http.Request, params []string) {
+	if p := s.findProject(w, params[0]); p == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"roles":    roles,
+		"services": []interface{}{},
+	})
+}
+
+func (s *Server) getUserRoles(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u.Roles)
+}
+
+func (s *Server) putUserRoles(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	req := struct {
+		RolesIds *[]string `json:"rolesIds"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RolesIds == nil {
+		writeError(w, http.StatusBadRequest, "Missing parameter rolesIds")
+		return
+	}
+
+	userRoles := []role{}
+	for _, id := range *req.RolesIds {
+		ro := findRole(func(r role) bool { return r.Id == id })
+		if ro == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid role id %s", id))
+			return
+		}
+		userRoles = append(userRoles, *ro)
+	}
+
+	u.Roles = userRoles
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (s *Server) getUserOpenrc(w http.ResponseWriter, r *http.Request, params []string) {
+	p, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		writeError(w, http.StatusBadRequest, "Missing parameter region")
+		return
+	}
+
+	var content string
+	switch version := r.URL.Query().Get("version"); version {
+	case "", "v2.0":
+		content = fmt.Sprintf(`#!/bin/bash
+export OS_AUTH_URL=https://auth.cloud.ovh.net/v2.0/
+export OS_TENANT_ID=%s
+export OS_TENANT_NAME="%d"
+export OS_USERNAME="%s"
+echo "Please enter your OpenStack Password: "
+read -sr OS_PASSWORD_INPUT
+export OS_PASSWORD=$OS_PASSWORD_INPUT
+export OS_REGION_NAME="%s"
+`, p.id, u.Id, u.Username, region)
+	case "v3":
+		content = fmt.Sprintf(`#!/bin/bash
+export OS_AUTH_URL=https://auth.cloud.ovh.net/v3/
+export OS_IDENTITY_API_VERSION=3
+export OS_USER_DOMAIN_NAME=${OS_USER_DOMAIN_NAME:-"Default"}
+export OS_PROJECT_DOMAIN_NAME=${OS_PROJECT_DOMAIN_NAME:-"Default"}
+export OS_TENANT_ID=%s
+export OS_TENANT_NAME="%d"
+export OS_USERNAME="%s"
+echo "Please enter your OpenStack Password: "
+read -sr OS_PASSWORD_INPUT
+export OS_PASSWORD=$OS_PASSWORD_INPUT
+export OS_REGION_NAME="%s"
+if [ -z "$OS_REGION_NAME" ]; then unset OS_REGION_NAME; fi
+`, p.id, u.Id, u.Username, region)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid openstack version %s", version))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"content": content})
+}
+
+//
+// S3 credentials & policies
+//
+
+func (s *Server) findS3Credential(w http.ResponseWriter, params []string) (*user, *s3Credential) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return nil, nil
+	}
+
+	c, ok := u.s3Credentials[params[2]]
+	if !ok {
+		notFound(w, "access", params[2])
+		return nil, nil
+	}
+	return u, c
+}
+
+func (s *Server) listS3Credentials(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	credentials := []s3Credential{}
+	for _, c := range u.s3Credentials {
+		withoutSecret := *c
+		withoutSecret.Secret = ""
+		credentials = append(credentials, withoutSecret)
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Access < credentials[j].Access })
+
+	writeJSON(w, http.StatusOK, credentials)
+}
+
+func (s *Server) postS3Credential(w http.ResponseWriter, r *http.Request, params []string) {
+	p, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	id := s.newId()
+	c := &s3Credential{
+		Access:   fmt.Sprintf("FAKEACCESSKEY%d", id),
+		Secret:   fmt.Sprintf("fake-secret-key-%d", s.newId()),
+		UserId:   fmt.Sprintf("openstack-user-%d", u.Id),
+		TenantId: p.id,
+	}
+	u.s3Credentials[c.Access] = c
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) getS3Credential(w http.ResponseWriter, r *http.Request, params []string) {
+	_, c := s.findS3Credential(w, params)
+	if c == nil {
+		return
+	}
+
+	withoutSecret := *c
+	withoutSecret.Secret = ""
+	writeJSON(w, http.StatusOK, withoutSecret)
+}
+
+func (s *Server) getS3CredentialSecret(w http.ResponseWriter, r *http.Request, params []string) {
+	_, c := s.findS3Credential(w, params)
+	if c == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"secret": c.Secret})
+}
+
+func (s *Server) deleteS3Credential(w http.ResponseWriter, r *http.Request, params []string) {
+	u, c := s.findS3Credential(w, params)
+	if c == nil {
+		return
+	}
+
+	delete(u.s3Credentials, c.Access)
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) getUserPolicy(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"policy": u.policy})
+}
+
+func (s *Server) postUserPolicy(w http.ResponseWriter, r *http.Request, params []string) {
+	_, u := s.findUser(w, params)
+	if u == nil {
+		return
+	}
+
+	req := struct {
+		Policy string `json:"policy"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	policy := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(req.Policy), &policy); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid policy: %s", err))
+		return
+	}
+
+	u.policy = req.Policy
+	writeJSON(w, http.StatusOK, nil)
+}
+
+//
+// Failover ips & instances
+//
+
+func (s *Server) findFailoverIp(w http.ResponseWriter, params []string) (*project, *failoverIp) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return nil, nil
+	}
+
+	ip, ok := p.failoverIps[params[1]]
+	if !ok {
+		notFound(w, "id", params[1])
+		return nil, nil
+	}
+	return p, ip
+}
+
+func (s *Server) listFailoverIps(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	ips := []*failoverIp{}
+	for _, ip := range p.failoverIps {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return ips[i].Id < ips[j].Id })
+
+	writeJSON(w, http.StatusOK, ips)
+}
+
+func (s *Server) getFailoverIp(w http.ResponseWriter, r *http.Request, params []string) {
+	_, ip := s.findFailoverIp(w, params)
+	if ip == nil {
+		return
+	}
+
+	if ip.Status == "operationPending" {
+		ip.Status = "ok"
+		ip.Progress = 100
+	}
+
+	writeJSON(w, http.StatusOK, ip)
+}
+
+func (s *Server) attachFailoverIp(w http.ResponseWriter, r *http.Request, params []string) {
+	p, ip := s.findFailoverIp(w, params)
+	if ip == nil {
+		return
+	}
+
+	req := struct {
+		InstanceId string `json:"instanceId"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, ok := p.instances[req.InstanceId]; !ok {
+		notFound(w, "instanceId", req.InstanceId)
+		return
+	}
+
+	if ip.Status == "operationPending" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("An operation is already pending on ip %s", ip.IP))
+		return
+	}
+
+	ip.RoutedTo = req.InstanceId
+	ip.Status = "operationPending"
+	ip.Progress = 0
+
+	writeJSON(w, http.StatusOK, ip)
+}
+
+func (s *Server) parkIp(w http.ResponseWriter, r *http.Request, params []string) {
+	for _, p := range s.projects {
+		for _, ip := range p.failoverIps {
+			if ip.Block != params[0] && ip.IP != params[0] {
+				continue
+			}
+
+			if ip.Status == "operationPending" {
+				writeError(w, http.StatusConflict, fmt.Sprintf("An operation is already pending on ip %s", ip.IP))
+				return
+			}
+
+			ip.RoutedTo = ""
+			ip.Status = "operationPending"
+			ip.Progress = 0
+
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"taskId":   s.newId(),
+				"function": "parkIp",
+				"status":   "todo",
+			})
+			return
+		}
+	}
+
+	notFound(w, "ip", params[0])
+}
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	instances := []*instance{}
+	for _, i := range p.instances {
+		instances = append(instances, i)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Id < instances[j].Id })
+
+	writeJSON(w, http.StatusOK, instances)
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request, params []string) {
+	p := s.findProject(w, params[0])
+	if p == nil {
+		return
+	}
+
+	i, ok := p.instances[params[1]]
+	if !ok {
+		notFound(w, "instanceId", params[1])
+		return
+	}
+
+	writeJSON(w, http.StatusOK, i)
+}
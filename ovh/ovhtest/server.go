@@ -0,0 +1,340 @@
+// Package ovhtest provides an in-memory, stateful fake of the subset of the
+// OVH API used by the terraform provider, so that acceptance tests can run
+// without an OVH account.
+//
+// The fake checks request signatures the same way the real API does and
+// simulates the asynchronous status transitions the resources wait for:
+// every authenticated GET on a pending object moves it one step forward.
+package ovhtest
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials and identifiers the fake server is seeded with.
+const (
+	ApplicationKey    = "fake-application-key"
+	ApplicationSecret = "fake-application-secret"
+	ConsumerKey       = "fake-consumer-key"
+	VRackId           = "pn-0000000"
+	ProjectId         = "0123456789abcdef0123456789abcdef"
+)
+
+// Server is a fake OVH API listening on a local http address.
+type Server struct {
+	*httptest.Server
+
+	ApplicationKey    string
+	ApplicationSecret string
+	ConsumerKey       string
+
+	mu             sync.Mutex
+	nextId         int
+	vracks         map[string]*vrack
+	projects       map[string]*project
+	failingRegions map[string]bool
+	failures       []failure
+	credential     *credential
+}
+
+// failure is an error answered instead of handling a request.
+type failure struct {
+	status     int
+	retryAfter string
+}
+
+// NewServer starts a fake OVH API seeded with one vrack, one public cloud
+// project with regions in Europe and North America, a couple of instances
+// and a failover ip.
+func NewServer() *Server {
+	s := &Server{
+		ApplicationKey:    ApplicationKey,
+		ApplicationSecret: ApplicationSecret,
+		ConsumerKey:       ConsumerKey,
+		nextId:            1000,
+		vracks:            map[string]*vrack{},
+		projects:          map[string]*project{},
+		failingRegions:    map[string]bool{},
+		credential:        newCredential(),
+	}
+
+	s.vracks[VRackId] = &vrack{
+		name:     VRackId,
+		projects: map[string]bool{},
+		tasks:    map[int]*vrackTask{},
+	}
+
+	p := newProject(ProjectId)
+	p.instances["instance-gra1-a"] = &instance{Id: "instance-gra1-a", Name: "fake-gra1-a", Region: "GRA1", Status: "ACTIVE"}
+	p.instances["instance-gra1-b"] = &instance{Id: "instance-gra1-b", Name: "fake-gra1-b", Region: "GRA1", Status: "ACTIVE"}
+	p.instances["instance-bhs1-a"] = &instance{Id: "instance-bhs1-a", Name: "fake-bhs1-a", Region: "BHS1", Status: "ACTIVE"}
+	p.instances["instance-gra7-a"] = &instance{Id: "instance-gra7-a", Name: "fake-gra7-a", Region: "GRA7", Status: "ACTIVE"}
+	p.instances["instance-waw1-a"] = &instance{Id: "instance-waw1-a", Name: "fake-waw1-a", Region: "WAW1", Status: "ACTIVE"}
+	p.failoverIps["failover-ip-fr"] = &failoverIp{
+		Id:            "failover-ip-fr",
+		IP:            "198.51.100.10",
+		Block:         "198.51.100.10/32",
+		ContinentCode: "EU",
+		GeoLocation:   "FR",
+		Status:        "ok",
+		SubType:       "ovh",
+		Progress:      100,
+	}
+	s.projects[ProjectId] = p
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// FailPrivateNetworkRegion makes private networks end up in ERROR instead of
+// ACTIVE in region from now on.
+func (s *Server) FailPrivateNetworkRegion(region string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failingRegions[region] = true
+}
+
+// FailNextRequests makes the next n authenticated requests fail with status,
+// along with a Retry-After header when retryAfter is set, before reaching
+// their handler.
+func (s *Server) FailNextRequests(n, status int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status, retryAfter: retryAfter})
+	}
+}
+
+// apiError is the body the OVH API returns along with a non 2xx status.
+type apiError struct {
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+type route struct {
+	method  string
+	pattern string
+	handler func(s *Server, w http.ResponseWriter, r *http.Request, params []string)
+}
+
+// routes are matched in order. A "*" segment matches any single escaped path
+// segment and is passed unescaped to the handler as a param.
+var routes = []route{
+	{"GET", "/me", (*Server).getMe},
+	{"GET", "/auth/currentCredential", (*Server).getCurrentCredential},
+
+	{"POST", "/vrack/*/cloudProject", (*Server).postVRackCloudProject},
+	{"GET", "/vrack/*/cloudProject/*", (*Server).getVRackCloudProject},
+	{"DELETE", "/vrack/*/cloudProject/*", (*Server).deleteVRackCloudProject},
+	{"GET", "/vrack/*/task/*", (*Server).getVRackTask},
+	{"GET", "/vrack/*", (*Server).getVRack},
+
+	{"GET", "/cloud/project/*", (*Server).getProject},
+	{"GET", "/cloud/project/*/region", (*Server).listRegions},
+	{"GET", "/cloud/project/*/region/*", (*Server).getRegion},
+
+	{"GET", "/cloud/project/*/network/private", (*Server).listPrivateNetworks},
+	{"POST", "/cloud/project/*/network/private", (*Server).postPrivateNetwork},
+	{"GET", "/cloud/project/*/network/private/*", (*Server).getPrivateNetwork},
+	{"PUT", "/cloud/project/*/network/private/*", (*Server).putPrivateNetwork},
+	{"DELETE", "/cloud/project/*/network/private/*", (*Server).deletePrivateNetwork},
+	{"POST", "/cloud/project/*/network/private/*/region", (*Server).postPrivateNetworkRegion},
+	{"GET", "/cloud/project/*/network/private/*/subnet", (*Server).listSubnets},
+	{"POST", "/cloud/project/*/network/private/*/subnet", (*Server).postSubnet},
+	{"DELETE", "/cloud/project/*/network/private/*/subnet/*", (*Server).deleteSubnet},
+
+	{"GET", "/cloud/project/*/user", (*Server).listUsers},
+	{"POST", "/cloud/project/*/user", (*Server).postUser},
+	{"GET", "/cloud/project/*/user/*", (*Server).getUser},
+	{"DELETE", "/cloud/project/*/user/*", (*Server).deleteUser},
+	{"POST", "/cloud/project/*/user/*/regeneratePassword", (*Server).regenerateUserPassword},
+	{"GET", "/cloud/project/*/user/*/openrc", (*Server).getUserOpenrc},
+	{"GET", "/cloud/project/*/user/*/role", (*Server).getUserRoles},
+	{"PUT", "/cloud/project/*/user/*/role", (*Server).putUserRoles},
+	{"GET", "/cloud/project/*/user/*/s3Credentials", (*Server).listS3Credentials},
+	{"POST", "/cloud/project/*/user/*/s3Credentials", (*Server).postS3Credential},
+	{"GET", "/cloud/project/*/user/*/s3Credentials/*", (*Server).getS3Credential},
+	{"DELETE", "/cloud/project/*/user/*/s3Credentials/*", (*Server).deleteS3Credential},
+	{"POST", "/cloud/project/*/user/*/s3Credentials/*/secret", (*Server).getS3CredentialSecret},
+	{"GET", "/cloud/project/*/user/*/policy", (*Server).getUserPolicy},
+	{"POST", "/cloud/project/*/user/*/policy", (*Server).postUserPolicy},
+	{"GET", "/cloud/project/*/role", (*Server).listRoles},
+
+	{"GET", "/cloud/project/*/ip/failover", (*Server).listFailoverIps},
+	{"GET", "/cloud/project/*/ip/failover/*", (*Server).getFailoverIp},
+	{"POST", "/cloud/project/*/ip/failover/*/attach", (*Server).attachFailoverIp},
+
+	{"GET", "/cloud/project/*/instance", (*Server).listInstances},
+	{"GET", "/cloud/project/*/instance/*", (*Server).getInstance},
+
+	{"POST", "/ip/*/park", (*Server).parkIp},
+}
+
+func matchRoute(pattern, path string) ([]string, bool) {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(segments) {
+		return nil, false
+	}
+
+	params := []string{}
+	for i := range ps {
+		if ps[i] == "*" {
+			param, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params = append(params, param)
+			continue
+		}
+		if ps[i] != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "couldn't read request body")
+		return
+	}
+
+	log.Printf("[DEBUG] fake OVH API: %s %s %s", r.Method, r.URL.RequestURI(), body)
+
+	if r.Method == "GET" && r.URL.Path == "/auth/time" {
+		writeJSON(w, http.StatusOK, time.Now().Unix())
+		return
+	}
+
+	if r.Method == "POST" && r.URL.Path == "/auth/credential" {
+		s.postCredential(w, withBody(r, body))
+		return
+	}
+
+	if status, msg := s.checkSignature(r, body); status != http.StatusOK {
+		writeError(w, status, msg)
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.failures) > 0 {
+		f := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+
+		if f.retryAfter != "" {
+			w.Header().Set("Retry-After", f.retryAfter)
+		}
+		writeError(w, f.status, http.StatusText(f.status))
+		return
+	}
+	s.mu.Unlock()
+
+	if r.URL.Path != "/auth/currentCredential" && !s.credentialValid() {
+		writeError(w, http.StatusForbidden, "This credential is not valid")
+		return
+	}
+
+	if !s.granted(r.Method, r.URL.Path) {
+		writeError(w, http.StatusForbidden, "This call has not been granted")
+		return
+	}
+
+	for _, rt := range routes {
+		if rt.method != r.Method {
+			continue
+		}
+		params, ok := matchRoute(rt.pattern, r.URL.EscapedPath())
+		if !ok {
+			continue
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		rt.handler(s, w, withBody(r, body), params)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Got an invalid (or empty) URL: %s %s", r.Method, r.URL.Path))
+}
+
+// checkSignature verifies the X-Ovh-* headers the same way the OVH API does.
+func (s *Server) checkSignature(r *http.Request, body []byte) (int, string) {
+	if r.Header.Get("X-Ovh-Application") != s.ApplicationKey {
+		return http.StatusForbidden, "Invalid application key"
+	}
+
+	if r.Header.Get("X-Ovh-Consumer") != s.ConsumerKey {
+		return http.StatusForbidden, "Invalid credential"
+	}
+
+	timestamp := r.Header.Get("X-Ovh-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return http.StatusBadRequest, "Invalid timestamp"
+	}
+
+	target := fmt.Sprintf("http://%s%s", r.Host, r.URL.RequestURI())
+	h := sha1.New()
+	h.Write([]byte(fmt.Sprintf("%s+%s+%s+%s+%s+%s",
+		s.ApplicationSecret,
+		s.ConsumerKey,
+		r.Method,
+		target,
+		body,
+		timestamp,
+	)))
+	if r.Header.Get("X-Ovh-Signature") != fmt.Sprintf("$1$%x", h.Sum(nil)) {
+		return http.StatusBadRequest, "Invalid signature"
+	}
+
+	return http.StatusOK, ""
+}
+
+func (s *Server) newId() int {
+	s.nextId++
+	return s.nextId
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"firstname": "Fake",
+		"name":      "Customer",
+		"nichandle": "fc00000-ovh",
+	})
+}
+
+func withBody(r *http.Request, body []byte) *http.Request {
+	r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	return r
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Message: message})
+}
@@ -0,0 +1,123 @@
+package ovhtest
+
+import (
+	"fmt"
+	"github.com/ovh/go-ovh/ovh"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, s *Server, appSecret string) *ovh.Client {
+	c, err := ovh.NewClient(s.URL, s.ApplicationKey, appSecret, s.ConsumerKey)
+	if err != nil {
+		t.Fatalf("couldn't create client: %s", err)
+	}
+	return c
+}
+
+func TestServer_signature(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	me := map[string]string{}
+	if err := newTestClient(t, s, s.ApplicationSecret).Get("/me", &me); err != nil {
+		t.Fatalf("signed request failed: %s", err)
+	}
+
+	err := newTestClient(t, s, "wrong-secret").Get("/me", &me)
+	if apiErr, ok := err.(*ovh.APIError); !ok || apiErr.Code != 400 {
+		t.Fatalf("expected a 400 APIError for a bad signature, got %v", err)
+	}
+}
+
+func TestServer_vrackTask(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, s.ApplicationSecret)
+
+	task := vrackTask{}
+	endpoint := fmt.Sprintf("/vrack/%s/cloudProject", VRackId)
+	if err := c.Post(endpoint, map[string]string{"project": ProjectId}, &task); err != nil {
+		t.Fatalf("attach failed: %s", err)
+	}
+
+	endpoint = fmt.Sprintf("/vrack/%s/task/%d", VRackId, task.Id)
+	for _, expected := range []string{"todo", "doing", "completed"} {
+		if err := c.Get(endpoint, &task); err != nil {
+			t.Fatalf("reading task failed: %s", err)
+		}
+		if task.Status != expected {
+			t.Fatalf("expected task status %s, got %s", expected, task.Status)
+		}
+	}
+
+	err := c.Get(endpoint, &task)
+	if apiErr, ok := err.(*ovh.APIError); !ok || apiErr.Code != 404 {
+		t.Fatalf("expected a 404 APIError for a completed task, got %v", err)
+	}
+
+	attachment := map[string]string{}
+	endpoint = fmt.Sprintf("/vrack/%s/cloudProject/%s", VRackId, ProjectId)
+	if err := c.Get(endpoint, &attachment); err != nil {
+		t.Fatalf("project should be attached once the task is completed: %s", err)
+	}
+}
+
+func TestServer_accessRules(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, s.ApplicationSecret)
+
+	s.SetAccessRules(AccessRule{Method: "GET", Path: "/vrack/*"})
+
+	if err := c.Get(fmt.Sprintf("/vrack/%s", VRackId), &map[string]interface{}{}); err != nil {
+		t.Fatalf("granted call failed: %s", err)
+	}
+
+	err := c.Get("/me", &map[string]interface{}{})
+	if apiErr, ok := err.(*ovh.APIError); !ok || apiErr.Code != 403 {
+		t.Fatalf("expected a 403 APIError for a call not granted, got %v", err)
+	}
+
+	err = c.Get("/auth/currentCredential", &map[string]interface{}{})
+	if apiErr, ok := err.(*ovh.APIError); !ok || apiErr.Code != 403 {
+		t.Fatalf("expected a 403 APIError reading the current credential without its rule, got %v", err)
+	}
+
+	s.SetAccessRules(AccessRule{Method: "GET", Path: "/vrack/*"}, AccessRule{Method: "GET", Path: "/auth/currentCredential"})
+
+	credential := map[string]interface{}{}
+	if err := c.Get("/auth/currentCredential", &credential); err != nil {
+		t.Fatalf("reading the current credential failed: %s", err)
+	}
+	if rules := credential["rules"].([]interface{}); len(rules) != 2 {
+		t.Errorf("expected 2 access rules, got %v", rules)
+	}
+}
+
+func TestServer_credentialValidity(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	c := newTestClient(t, s, s.ApplicationSecret)
+
+	s.SetCredentialExpiration(time.Now().Add(-time.Hour))
+
+	err := c.Get("/me", &map[string]interface{}{})
+	if apiErr, ok := err.(*ovh.APIError); !ok || apiErr.Code != 403 {
+		t.Fatalf("expected a 403 APIError for an expired credential, got %v", err)
+	}
+
+	credential := map[string]interface{}{}
+	if err := c.Get("/auth/currentCredential", &credential); err != nil {
+		t.Fatalf("reading the current credential failed: %s", err)
+	}
+	if credential["expiration"] == nil {
+		t.Errorf("expected the expiration to be set, got %v", credential)
+	}
+
+	s.SetCredentialExpiration(time.Now().Add(time.Hour))
+	s.SetCredentialStatus("pendingValidation")
+	if err := c.Get("/me", &map[string]interface{}{}); err == nil {
+		t.Errorf("expected calls to be refused for a credential pending validation")
+	}
+}
@@ -0,0 +1,163 @@
+package ovhtest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type vrack struct {
+	name     string
+	projects map[string]bool
+	tasks    map[int]*vrackTask
+}
+
+type vrackTask struct {
+	Id           int       `json:"id"`
+	Function     string    `json:"function"`
+	TargetDomain string    `json:"targetDomain"`
+	Status       string    `json:"status"`
+	ServiceName  string    `json:"serviceName"`
+	OrderId      int       `json:"orderId"`
+	LastUpdate   time.Time `json:"lastUpdate"`
+	TodoDate     time.Time `json:"todoDate"`
+
+	// apply is run once the task reaches the completed status.
+	apply func()
+}
+
+// vrackTaskSteps is the sequence of statuses a vrack task goes through. Once
+// completed, the task is removed and subsequent reads answer with a 404.
+var vrackTaskSteps = []string{"init", "todo", "doing", "completed"}
+
+func (s *Server) findVRack(w http.ResponseWriter, name string) *vrack {
+	v, ok := s.vracks[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("The requested object (serviceName = %s) does not exist", name))
+		return nil
+	}
+	return v
+}
+
+func (s *Server) newVRackTask(v *vrack, function, target string, apply func()) *vrackTask {
+	now := time.Now()
+	t := &vrackTask{
+		Id:           s.newId(),
+		Function:     function,
+		TargetDomain: target,
+		Status:       vrackTaskSteps[0],
+		ServiceName:  v.name,
+		LastUpdate:   now,
+		TodoDate:     now,
+		apply:        apply,
+	}
+	v.tasks[t.Id] = t
+	return t
+}
+
+func (s *Server) getVRack(w http.ResponseWriter, r *http.Request, params []string) {
+	v := s.findVRack(w, params[0])
+	if v == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"name":        v.name,
+		"description": "fake vrack",
+	})
+}
+
+func (s *Server) postVRackCloudProject(w http.ResponseWriter, r *http.Request, params []string) {
+	v := s.findVRack(w, params[0])
+	if v == nil {
+		return
+	}
+
+	req := struct {
+		Project string `json:"project"`
+	}{}
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, ok := s.projects[req.Project]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Project %s is not eligible", req.Project))
+		return
+	}
+
+	if v.projects[req.Project] {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Project %s is already in vrack %s", req.Project, v.name))
+		return
+	}
+
+	t := s.newVRackTask(v, "addCloudProjectToVrack", req.Project, func() {
+		v.projects[req.Project] = true
+	})
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) getVRackCloudProject(w http.ResponseWriter, r *http.Request, params []string) {
+	v := s.findVRack(w, params[0])
+	if v == nil {
+		return
+	}
+
+	if !v.projects[params[1]] {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("The requested object (project = %s) does not exist", params[1]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"vrack":   v.name,
+		"project": params[1],
+	})
+}
+
+func (s *Server) deleteVRackCloudProject(w http.ResponseWriter, r *http.Request, params []string) {
+	v := s.findVRack(w, params[0])
+	if v == nil {
+		return
+	}
+
+	project := params[1]
+	if !v.projects[project] {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("The requested object (project = %s) does not exist", project))
+		return
+	}
+
+	t := s.newVRackTask(v, "removeCloudProjectFromVrack", project, func() {
+		delete(v.projects, project)
+	})
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) getVRackTask(w http.ResponseWriter, r *http.Request, params []string) {
+	v := s.findVRack(w, params[0])
+	if v == nil {
+		return
+	}
+
+	id, _ := strconv.Atoi(params[1])
+	t, ok := v.tasks[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("The requested object (taskId = %s) does not exist", params[1]))
+		return
+	}
+
+	for i := range vrackTaskSteps {
+		if vrackTaskSteps[i] == t.Status && i+1 < len(vrackTaskSteps) {
+			t.Status = vrackTaskSteps[i+1]
+			t.LastUpdate = time.Now()
+			break
+		}
+	}
+
+	if t.Status == "completed" {
+		t.apply()
+		delete(v.tasks, id)
+	}
+
+	writeJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,92 @@
+package ovh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/openpgp"
+	"strings"
+
+	// keys without hash preferences only accept RIPEMD160
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+const pgpKeybasePrefix = "keybase:"
+
+// pgpReadPublicKey reads the public key of a pgp_key argument, either an
+// ASCII armored key or a base64 encoded binary key such as the output of
+// `gpg --export <key> | base64`.
+//
+// Keys are only ever read from the literal: the provider doesn't reach
+// keybase, so keybase:username references are refused with a hint to
+// export the key.
+func pgpReadPublicKey(key string) (*openpgp.Entity, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("empty PGP key")
+	}
+
+	if strings.HasPrefix(key, pgpKeybasePrefix) {
+		user := strings.TrimPrefix(key, pgpKeybasePrefix)
+		return nil, fmt.Errorf("keybase references are not resolved by the provider, "+
+			"use the literal key instead, e.g. `curl https://keybase.io/%s/pgp_keys.asc`", user)
+	}
+
+	var entities openpgp.EntityList
+	var err error
+	if strings.HasPrefix(key, "-----BEGIN PGP") {
+		entities, err = openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	} else {
+		raw, decodeErr := base64.StdEncoding.DecodeString(key)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("PGP key is neither ASCII armored nor base64 encoded: %s", decodeErr)
+		}
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(raw))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read PGP key: %s", err)
+	}
+
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("expected a single PGP key, got %d", len(entities))
+	}
+
+	return entities[0], nil
+}
+
+// pgpKeyFingerprint returns the hex encoded fingerprint of the primary key.
+func pgpKeyFingerprint(entity *openpgp.Entity) string {
+	return hex.EncodeToString(entity.PrimaryKey.Fingerprint[:])
+}
+
+// pgpEncryptValue encrypts value for key and returns the fingerprint of the
+// key along with the base64 encoded binary message, which decrypts with
+// `base64 --decode | gpg --decrypt`.
+func pgpEncryptValue(key, value string) (string, string, error) {
+	entity, err := pgpReadPublicKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := openpgp.Encrypt(buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't encrypt with PGP key %s: %s", pgpKeyFingerprint(entity), err)
+	}
+	if _, err := w.Write([]byte(value)); err != nil {
+		return "", "", fmt.Errorf("couldn't encrypt with PGP key %s: %s", pgpKeyFingerprint(entity), err)
+	}
+	if err := w.Close(); err != nil {
+		return "", "", fmt.Errorf("couldn't encrypt with PGP key %s: %s", pgpKeyFingerprint(entity), err)
+	}
+
+	return pgpKeyFingerprint(entity), base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func validatePGPKey(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := pgpReadPublicKey(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q: %s", k, err))
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package ovh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"io/ioutil"
+	"testing"
+)
+
+func testPGPEntity(t *testing.T) (*openpgp.Entity, string, string) {
+	entity, err := openpgp.NewEntity("terraform", "acceptance tests", "terraform@example.com", nil)
+	if err != nil {
+		t.Fatalf("couldn't generate PGP key: %s", err)
+	}
+
+	raw := &bytes.Buffer{}
+	if err := entity.Serialize(raw); err != nil {
+		t.Fatalf("couldn't serialize PGP key: %s", err)
+	}
+
+	armored := &bytes.Buffer{}
+	w, err := armor.Encode(armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(raw.Bytes())
+	w.Close()
+
+	return entity, armored.String(), base64.StdEncoding.EncodeToString(raw.Bytes())
+}
+
+func testPGPDecrypt(t *testing.T, entity *openpgp.Entity, encrypted string) string {
+	raw, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("encrypted value isn't base64 encoded: %s", err)
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(raw), openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatalf("couldn't decrypt value: %s", err)
+	}
+
+	value, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatalf("couldn't decrypt value: %s", err)
+	}
+	return string(value)
+}
+
+func TestPGPEncryptValue(t *testing.T) {
+	entity, armored, encoded := testPGPEntity(t)
+
+	for name, key := range map[string]string{"armored": armored, "base64": encoded} {
+		fingerprint, encrypted, err := pgpEncryptValue(key, "secret")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+			continue
+		}
+
+		if fingerprint != pgpKeyFingerprint(entity) {
+			t.Errorf("%s: expected fingerprint %s, got %s", name, pgpKeyFingerprint(entity), fingerprint)
+		}
+		if value := testPGPDecrypt(t, entity, encrypted); value != "secret" {
+			t.Errorf("%s: expected to decrypt %q, got %q", name, "secret", value)
+		}
+	}
+}
+
+func TestValidatePGPKey(t *testing.T) {
+	_, armored, encoded := testPGPEntity(t)
+
+	cases := []struct {
+		key   string
+		valid bool
+	}{
+		{armored, true},
+		{encoded, true},
+		{"keybase:someone", false},
+		{"not a key", false},
+		{base64.StdEncoding.EncodeToString([]byte("not a key")), false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		_, errs := validatePGPKey(c.key, "pgp_key")
+		if c.valid && len(errs) > 0 {
+			t.Errorf("expected %q to be valid, got %v", c.key, errs)
+		}
+		if !c.valid && len(errs) == 0 {
+			t.Errorf("expected %q to be invalid", c.key)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package ovh
 
 import (
+	"fmt"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
+	"time"
 )
 
 // Provider returns a schema.Provider for OVH.
@@ -10,9 +13,10 @@ func Provider() terraform.ResourceProvider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"endpoint": &schema.Schema{
-				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("OVH_ENDPOINT", nil),
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OVH_ENDPOINT", nil),
+				ValidateFunc: validateOVHEndpoint,
 			},
 			"application_key": &schema.Schema{
 				Type:        schema.TypeString,
@@ -29,6 +33,44 @@ func Provider() terraform.ResourceProvider {
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OVH_CONSUMER_KEY", ""),
 			},
+			"config_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OVH_CONFIG_FILE", ""),
+			},
+			"profile": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OVH_PROFILE", ""),
+			},
+			"default_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OVH_DEFAULT_TIMEOUT", nil),
+				ValidateFunc: validateDuration,
+			},
+			"regenerate_password_on_import": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"credential_expiry_warning": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OVH_CREDENTIAL_EXPIRY_WARNING", "168h"),
+				ValidateFunc: validateDuration,
+			},
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("OVH_MAX_RETRIES", 3),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"ovh_current_credential":     dataSourceCurrentCredential(),
+			"ovh_publiccloud_user_roles": dataSourcePublicCloudUserRoles(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -36,6 +78,8 @@ func Provider() terraform.ResourceProvider {
 			"ovh_publiccloud_private_network":        resourcePublicCloudPrivateNetwork(),
 			"ovh_publiccloud_private_network_subnet": resourcePublicCloudPrivateNetworkSubnet(),
 			"ovh_publiccloud_user":                   resourcePublicCloudUser(),
+			"ovh_publiccloud_user_s3_credential":     resourcePublicCloudUserS3Credential(),
+			"ovh_publiccloud_user_s3_policy":         resourcePublicCloudUserS3Policy(),
 			"ovh_publiccloud_failover_ip":            resourcePublicCloudFailoverIp(),
 		},
 
@@ -49,6 +93,27 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 		ApplicationKey:    d.Get("application_key").(string),
 		ApplicationSecret: d.Get("application_secret").(string),
 		ConsumerKey:       d.Get("consumer_key").(string),
+		ConfigFile:        d.Get("config_file").(string),
+		Profile:           d.Get("profile").(string),
+
+		RegeneratePasswordOnImport: d.Get("regenerate_password_on_import").(bool),
+		MaxRetries:                 d.Get("max_retries").(int),
+	}
+
+	if v := d.Get("default_timeout").(string); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default_timeout %q: %s", v, err)
+		}
+		config.DefaultTimeout = timeout
+	}
+
+	if v := d.Get("credential_expiry_warning").(string); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential_expiry_warning %q: %s", v, err)
+		}
+		config.CredentialExpiryWarning = window
 	}
 
 	if err := config.loadAndValidate(); err != nil {
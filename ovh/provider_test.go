@@ -2,9 +2,10 @@ package ovh
 
 import (
 	"fmt"
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/ovh/go-ovh/ovh"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
 	"log"
 	"os"
 	"testing"
@@ -12,7 +13,7 @@ import (
 
 var testAccProviders map[string]terraform.ResourceProvider
 var testAccProvider *schema.Provider
-var testAccOVHClient *ovh.Client
+var testAccOVHClient *OVHClient
 
 func init() {
 	log.SetOutput(os.Stdout)
@@ -22,6 +23,34 @@ func init() {
 	}
 }
 
+// TestMain points the acceptance tests to an in-memory fake of the OVH API
+// when OVH_FAKE_API is set, so they can run without an OVH account. Unless
+// running acceptance tests against the real API, asynchronous operations
+// are polled without delay.
+func TestMain(m *testing.M) {
+	if os.Getenv(resource.TestEnvVar) == "" || os.Getenv("OVH_FAKE_API") != "" {
+		operationPollDelay = 0
+		operationPollMinTimeout = 0
+	}
+
+	if os.Getenv("OVH_FAKE_API") == "" {
+		os.Exit(m.Run())
+	}
+
+	srv := ovhtest.NewServer()
+
+	os.Setenv("OVH_ENDPOINT", srv.URL)
+	os.Setenv("OVH_APPLICATION_KEY", srv.ApplicationKey)
+	os.Setenv("OVH_APPLICATION_SECRET", srv.ApplicationSecret)
+	os.Setenv("OVH_CONSUMER_KEY", srv.ConsumerKey)
+	os.Setenv("OVH_VRACK", ovhtest.VRackId)
+	os.Setenv("OVH_PUBLIC_CLOUD", ovhtest.ProjectId)
+
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -32,6 +61,26 @@ func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
 
+// testFakeConfig returns a provider configuration logged in on a fresh fake
+// OVH API, to unit test resources functions.
+func testFakeConfig(t *testing.T) (*Config, *ovhtest.Server) {
+	srv := ovhtest.NewServer()
+
+	config := &Config{
+		Endpoint:          srv.URL,
+		ApplicationKey:    srv.ApplicationKey,
+		ApplicationSecret: srv.ApplicationSecret,
+		ConsumerKey:       srv.ConsumerKey,
+	}
+
+	if err := config.loadAndValidate(); err != nil {
+		srv.Close()
+		t.Fatalf("couln't load OVH Client: %s", err)
+	}
+
+	return config, srv
+}
+
 func testAccPreCheck(t *testing.T) {
 	v := os.Getenv("OVH_ENDPOINT")
 	if v == "" {
@@ -0,0 +1,76 @@
+package ovh
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+type PublicCloudRegion struct {
+	Name               string `json:"name"`
+	ContinentCode      string `json:"continentCode"`
+	DatacenterLocation string `json:"datacenterLocation"`
+	Status             string `json:"status"`
+}
+
+// publicCloudRegionCache holds the regions of every public cloud project
+// read during a provider run, as they don't change while terraform runs.
+type publicCloudRegionCache struct {
+	sync.Mutex
+	regions map[string][]PublicCloudRegion
+}
+
+func newPublicCloudRegionCache() *publicCloudRegionCache {
+	return &publicCloudRegionCache{
+		regions: map[string][]PublicCloudRegion{},
+	}
+}
+
+// PublicCloudRegions returns the regions available to the project, reading
+// them from the API only once per provider run.
+func (c *Config) PublicCloudRegions(projectId string) ([]PublicCloudRegion, error) {
+	if c.publicCloudRegions == nil {
+		return GetPublicCloudRegions(c, projectId)
+	}
+
+	c.publicCloudRegions.Lock()
+	defer c.publicCloudRegions.Unlock()
+
+	if regions, ok := c.publicCloudRegions.regions[projectId]; ok {
+		return regions, nil
+	}
+
+	regions, err := GetPublicCloudRegions(c, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.publicCloudRegions.regions[projectId] = regions
+	return regions, nil
+}
+
+func GetPublicCloudRegions(c *Config, projectId string) ([]PublicCloudRegion, error) {
+	names := []string{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/region", projectId)
+
+	err := c.OVHClient.Get(endpoint, &names)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+	}
+
+	regions := make([]PublicCloudRegion, 0, len(names))
+	for _, name := range names {
+		region := PublicCloudRegion{}
+		endpoint := fmt.Sprintf("/cloud/project/%s/region/%s", projectId, name)
+
+		err := c.OVHClient.Get(endpoint, &region)
+		if err != nil {
+			return nil, fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+		}
+
+		regions = append(regions, region)
+	}
+
+	log.Printf("[DEBUG] Read public cloud project %s regions: %v", projectId, regions)
+	return regions, nil
+}
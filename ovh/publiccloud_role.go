@@ -0,0 +1,59 @@
+package ovh
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+type PublicCloudRole struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func (r PublicCloudRole) String() string {
+	return fmt.Sprintf("Role[Id: %s, Name: %s]", r.Id, r.Name)
+}
+
+type publicCloudRolesResponse struct {
+	Roles []PublicCloudRole `json:"roles"`
+}
+
+// GetPublicCloudRoles returns the user roles available to the project.
+func GetPublicCloudRoles(c *Config, projectId string) ([]PublicCloudRole, error) {
+	r := &publicCloudRolesResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/role", projectId)
+
+	err := c.OVHClient.Get(endpoint, r)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+	}
+
+	log.Printf("[DEBUG] Read public cloud project %s roles: %v", projectId, r.Roles)
+	return r.Roles, nil
+}
+
+// publicCloudRoleIds maps role names to the ids of the project roles,
+// failing on the first unknown name with the list of available ones.
+func publicCloudRoleIds(roles []PublicCloudRole, names []string) ([]string, error) {
+	byName := make(map[string]string, len(roles))
+	available := make([]string, 0, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role.Id
+		available = append(available, role.Name)
+	}
+	sort.Strings(available)
+
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q, available roles are: %s", name, strings.Join(available, ", "))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
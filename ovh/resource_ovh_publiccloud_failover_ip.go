@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/ovh/go-ovh/ovh"
 	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -22,11 +25,35 @@ type PublicCloudFailoverIp struct {
 	Regions       []string
 }
 
+func (p *PublicCloudFailoverIp) String() string {
+	return fmt.Sprintf("FailoverIp[Id: %s, IP: %s, Status: %s, RoutedTo: %s, Progress: %d]", p.Id, p.IP, p.Status, p.RoutedTo, p.Progress)
+}
+
+var publicCloudFailoverIpAccessRules = []AccessRule{
+	{"GET", "/cloud/project/*/ip/failover"},
+	{"GET", "/cloud/project/*/ip/failover/*"},
+	{"POST", "/cloud/project/*/ip/failover/*/attach"},
+	{"POST", "/ip/*/park"},
+	{"GET", "/cloud/project/*/instance/*"},
+	{"GET", "/cloud/project/*/region"},
+	{"GET", "/cloud/project/*/region/*"},
+}
+
 func resourcePublicCloudFailoverIp() *schema.Resource {
 	return &schema.Resource{
 		Create: resourcePublicCloudFailoverIpCreate,
 		Read:   resourcePublicCloudFailoverIpRead,
+		Update: resourcePublicCloudFailoverIpUpdate,
 		Delete: resourcePublicCloudFailoverIpDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourcePublicCloudFailoverIpImportState,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(unsetTimeout),
+			Update: schema.DefaultTimeout(unsetTimeout),
+			Delete: schema.DefaultTimeout(unsetTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"ip_address": {
@@ -44,7 +71,10 @@ func resourcePublicCloudFailoverIp() *schema.Resource {
 			"instance_id": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+			},
+			"fallback_instance_id": {
+				Type:     schema.TypeString,
+				Optional: true,
 			},
 			"project_id": {
 				Type:        schema.TypeString,
@@ -56,6 +86,26 @@ func resourcePublicCloudFailoverIp() *schema.Resource {
 	}
 }
 
+// pcfoipImportID matches import ids of the form project_id/ip_id
+var pcfoipImportID = regexp.MustCompile("^([^/]+)/([^/]+)$")
+
+// resourcePublicCloudFailoverIpImportState imports failover ips from
+// project_id/ip_id ids. A bare ip_id is also accepted, the project being
+// read from OVH_PROJECT_ID.
+func resourcePublicCloudFailoverIpImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if params := pcfoipImportID.FindStringSubmatch(d.Id()); params != nil {
+		d.Set("project_id", params[1])
+		d.SetId(params[2])
+	} else if strings.Contains(d.Id(), "/") {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id nor ip id from id %q, expected project_id/ip_id", d.Id())
+	} else {
+		d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
+	}
+
+	d.Set("ip_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourcePublicCloudFailoverIpCreate(d *schema.ResourceData, meta interface{}) error {
 	Config := meta.(*Config)
 
@@ -64,68 +114,189 @@ func resourcePublicCloudFailoverIpCreate(d *schema.ResourceData, meta interface{
 		return err
 	}
 
-	instance, err := GetPublicCloudInstance(d, Config)
+	instance, err := publicCloudFailoverIpCheckInstance(d, Config, ip)
 	if err != nil {
 		return err
 	}
 
-	if !stringInSlice(instance.Region, ip.Regions) {
-		return fmt.Errorf("[ERROR] IP %s cannot be used in region %s", ip.IP, instance.Region)
+	err = publicCloudFailoverIpAttach(Config.OVHClient, d.Get("project_id").(string), ip, instance.Id, operationTimeout(d, meta, schema.TimeoutCreate))
+	if err != nil {
+		return err
 	}
 
-	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/%s/attach", d.Get("project_id").(string), ip.Id)
-	response := PublicCloudFailoverIp{}
-	request := map[string]string{
-		"instanceId": d.Get("instance_id").(string),
+	d.SetId(ip.Id)
+
+	log.Printf("[DEBUG] Attatched failover ip (%s, %s) to instance %s", ip.Id, ip.IP, instance.Id)
+
+	return resourcePublicCloudFailoverIpRead(d, meta)
+}
+
+func resourcePublicCloudFailoverIpRead(d *schema.ResourceData, meta interface{}) error {
+	Config := meta.(*Config)
+
+	ipId := d.Get("ip_id").(string)
+	if ipId == "" {
+		ip, err := publicCloudGetFailoverIpFromConfig(d, Config)
+		if err != nil {
+			return err
+		}
+		ipId = ip.Id
 	}
 
-	err = Config.OVHClient.Post(endpoint, request, &response)
+	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/%s", d.Get("project_id").(string), ipId)
+	ip := PublicCloudFailoverIp{}
+
+	err := Config.OVHClient.Get(endpoint, &ip)
 	if err != nil {
-		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+		return CheckDeleted(d, err, endpoint)
 	}
 
-	stateConf := &resource.StateChangeConf{
-		Pending:    []string{"operationPending"},
-		Target:     []string{"ok"},
-		Refresh:    publicCloudFailoverIpRefreshFunc(Config.OVHClient, d.Get("project_id").(string), ip),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
-	}
+	d.Set("ip_address", ip.IP)
+	d.Set("ip_id", ip.Id)
+	d.Set("instance_id", ip.RoutedTo)
+
+	// ids used to embed the instance id, which changes when the ip moves
+	d.SetId(ip.Id)
+
+	return nil
+}
 
-	_, err = stateConf.WaitForState()
+// publicCloudFailoverIpCheckInstance returns the instance set in the
+// configuration if the failover ip can be routed to its region.
+func publicCloudFailoverIpCheckInstance(d *schema.ResourceData, c *Config, ip PublicCloudFailoverIp) (PublicCloudInstance, error) {
+	instance, err := GetPublicCloudInstance(d, c)
 	if err != nil {
-		return fmt.Errorf("[ERROR] Waiting for failover ip (%s, %s): %s", ip.Id, ip.IP, err)
+		return PublicCloudInstance{}, err
+	}
+
+	if !stringInSlice(instance.Region, ip.Regions) {
+		return PublicCloudInstance{}, fmt.Errorf("[ERROR] IP %s cannot be used in region %s", ip.IP, instance.Region)
 	}
 
-	d.SetId(fmt.Sprintf("failover_ip_%s-instance_%s", ip.Id, instance.Id))
+	return instance, nil
+}
+
+// resourcePublicCloudFailoverIpUpdate moves the failover ip to the new
+// instance. fallback_instance_id is only used on deletion, there's nothing
+// to update on the OVH side when it changes.
+func resourcePublicCloudFailoverIpUpdate(d *schema.ResourceData, meta interface{}) error {
+	Config := meta.(*Config)
+
+	if d.HasChange("instance_id") {
+		ip, err := publicCloudGetFailoverIpFromConfig(d, Config)
+		if err != nil {
+			return err
+		}
+
+		instance, err := publicCloudFailoverIpCheckInstance(d, Config, ip)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] Will move failover ip (%s, %s) from instance %q to %s", ip.Id, ip.IP, ip.RoutedTo, instance.Id)
 
-	log.Printf("[DEBUG] Attatched failover ip (%s, %s)", ip.Id, ip.IP)
+		err = publicCloudFailoverIpAttach(Config.OVHClient, d.Get("project_id").(string), ip, instance.Id, operationTimeout(d, meta, schema.TimeoutUpdate))
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] Moved failover ip (%s, %s) to instance %s", ip.Id, ip.IP, instance.Id)
+	}
 
 	return resourcePublicCloudFailoverIpRead(d, meta)
 }
 
-func resourcePublicCloudFailoverIpRead(d *schema.ResourceData, meta interface{}) error {
+func resourcePublicCloudFailoverIpDelete(d *schema.ResourceData, meta interface{}) error {
 	Config := meta.(*Config)
 
+	projectId := d.Get("project_id").(string)
+	instanceId := d.Get("instance_id").(string)
+	fallbackId := d.Get("fallback_instance_id").(string)
+	timeout := operationTimeout(d, meta, schema.TimeoutDelete)
+
 	ip, err := publicCloudGetFailoverIpFromConfig(d, Config)
 	if err != nil {
 		return err
 	}
 
-	d.Set("ip_address", ip.IP)
-	d.Set("ip_id", ip.Id)
-	d.Set("instance_id", ip.RoutedTo)
+	if ip.RoutedTo != instanceId {
+		log.Printf("[WARN] failover ip (%s, %s) is routed to %q instead of %s, leaving it as is", ip.Id, ip.IP, ip.RoutedTo, instanceId)
+		d.SetId("")
+		return nil
+	}
 
+	if fallbackId != "" {
+		log.Printf("[DEBUG] Will route failover ip (%s, %s) to fallback instance %s", ip.Id, ip.IP, fallbackId)
+		err = publicCloudFailoverIpAttach(Config.OVHClient, projectId, ip, fallbackId, timeout)
+	} else {
+		log.Printf("[DEBUG] Will un-route failover ip (%s, %s) from instance %s", ip.Id, ip.IP, instanceId)
+		err = publicCloudFailoverIpPark(Config.OVHClient, projectId, ip, timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.SetId("")
+
+	log.Printf("[DEBUG] Detached failover ip (%s, %s) from instance %s", ip.Id, ip.IP, instanceId)
 	return nil
 }
 
-//noinspection GoUnusedParameter
-func resourcePublicCloudFailoverIpDelete(d *schema.ResourceData, meta interface{}) error {
+// publicCloudFailoverIpAttach routes the failover ip to the instance and
+// waits for the operation to complete.
+func publicCloudFailoverIpAttach(c *OVHClient, projectId string, ip PublicCloudFailoverIp, instanceId string, timeout time.Duration) error {
+	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/%s/attach", projectId, ip.Id)
+	response := PublicCloudFailoverIp{}
+	request := map[string]string{
+		"instanceId": instanceId,
+	}
+
+	err := c.Post(endpoint, request, &response)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling %s with instance %s:\n\t %q", endpoint, instanceId, err)
+	}
+
+	return publicCloudFailoverIpWait(c, projectId, ip, timeout)
+}
+
+// publicCloudFailoverIpPark un-routes the failover ip and waits for the
+// operation to complete. The public cloud API can only move failover ips
+// between instances, parking goes through the generic /ip API.
+func publicCloudFailoverIpPark(c *OVHClient, projectId string, ip PublicCloudFailoverIp, timeout time.Duration) error {
+	block := ip.Block
+	if block == "" {
+		block = ip.IP + "/32"
+	}
+
+	endpoint := fmt.Sprintf("/ip/%s/park", url.PathEscape(block))
+
+	err := c.Post(endpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+	}
+
+	return publicCloudFailoverIpWait(c, projectId, ip, timeout)
+}
+
+func publicCloudFailoverIpWait(c *OVHClient, projectId string, ip PublicCloudFailoverIp, timeout time.Duration) error {
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"operationPending"},
+		Target:     []string{"ok"},
+		Refresh:    publicCloudFailoverIpRefreshFunc(c, projectId, ip),
+		Timeout:    timeout,
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
+	}
+
+	_, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("[ERROR] Waiting for failover ip (%s, %s): %s", ip.Id, ip.IP, err)
+	}
+
 	return nil
 }
 
-func publicCloudFailoverIpRefreshFunc(c *ovh.Client, projectId string, ip PublicCloudFailoverIp) resource.StateRefreshFunc {
+func publicCloudFailoverIpRefreshFunc(c *OVHClient, projectId string, ip PublicCloudFailoverIp) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := &PublicCloudFailoverIp{}
 		endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/%s", projectId, ip.Id)
@@ -142,19 +313,27 @@ func publicCloudFailoverIpRefreshFunc(c *ovh.Client, projectId string, ip Public
 func publicCloudGetFailoverIpFromConfig(d *schema.ResourceData, c *Config) (PublicCloudFailoverIp, error) {
 	projectId := d.Get("project_id").(string)
 
+	var ip PublicCloudFailoverIp
+	var err error
+
 	if ipId := d.Get("ip_id").(string); ipId != "" {
-		return PublicCloudGetFailoverIpById(c.OVHClient, projectId, ipId)
+		ip, err = PublicCloudGetFailoverIpById(c.OVHClient, projectId, ipId)
+	} else if ipAddress := d.Get("ip_address").(string); ipAddress != "" {
+		ip, err = PublicCloudGetFailoverIpByAddress(c.OVHClient, projectId, ipAddress)
+	} else {
+		//noinspection GoPlaceholderCount
+		return PublicCloudFailoverIp{}, fmt.Errorf("You must specify a ip_address or ip_id.")
 	}
 
-	if ipAddress := d.Get("ip_address").(string); ipAddress != "" {
-		return PublicCloudGetFailoverIpByAddress(c.OVHClient, projectId, ipAddress)
+	if err != nil {
+		return PublicCloudFailoverIp{}, err
 	}
 
-	//noinspection GoPlaceholderCount
-	return PublicCloudFailoverIp{}, fmt.Errorf("You must specify a ip_address or ip_id.")
+	ip.Regions = publicCloudGetFailoverIpRegions(c, projectId, ip)
+	return ip, nil
 }
 
-func PublicCloudGetFailoverIpById(c *ovh.Client, projectID string, ipId string) (PublicCloudFailoverIp, error) {
+func PublicCloudGetFailoverIpById(c *OVHClient, projectID string, ipId string) (PublicCloudFailoverIp, error) {
 	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/%s", projectID, ipId)
 	ip := PublicCloudFailoverIp{}
 
@@ -163,10 +342,10 @@ func PublicCloudGetFailoverIpById(c *ovh.Client, projectID string, ipId string)
 		return PublicCloudFailoverIp{}, fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
 	}
 
-	return publicCloudGetFailoverIpRegions(ip), nil
+	return ip, nil
 }
 
-func PublicCloudGetFailoverIpByAddress(c *ovh.Client, projectID string, ipAddress string) (PublicCloudFailoverIp, error) {
+func PublicCloudGetFailoverIpByAddress(c *OVHClient, projectID string, ipAddress string) (PublicCloudFailoverIp, error) {
 	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover", projectID)
 	response := []PublicCloudFailoverIp{}
 
@@ -177,33 +356,54 @@ func PublicCloudGetFailoverIpByAddress(c *ovh.Client, projectID string, ipAddres
 
 	for i := 0; i < len(response); i++ {
 		if response[i].IP == ipAddress {
-			return publicCloudGetFailoverIpRegions(response[i]), nil
+			return response[i], nil
 		}
 	}
 
 	return PublicCloudFailoverIp{}, fmt.Errorf("[ERROR] IP Address does not exist: %s", ipAddress)
 }
 
-func publicCloudGetFailoverIpRegions(ip PublicCloudFailoverIp) PublicCloudFailoverIp {
-	ipRegions := map[string][]string{
-		"BE": {"GRA1", "SBG1"},
-		"CA": {"BHS1"},
-		"CZ": {"GRA1", "SBG1"},
-		"FI": {"GRA1", "SBG1"},
-		"FR": {"GRA1", "SBG1"},
-		"DE": {"GRA1", "SBG1"},
-		"IE": {"GRA1", "SBG1"},
-		"IT": {"GRA1", "SBG1"},
-		"LT": {"GRA1", "SBG1"},
-		"NL": {"GRA1", "SBG1"},
-		"PL": {"GRA1", "SBG1"},
-		"PT": {"GRA1", "SBG1"},
-		"ES": {"GRA1", "SBG1"},
-		"UK": {"GRA1", "SBG1"},
-		"US": {"BHS1"},
-	}
-
-	ip.Regions = ipRegions[ip.GeoLocation]
-
-	return ip
+// publicCloudGetFailoverIpRegions returns the project regions the failover ip
+// can be routed to: the ones on the same continent as the ip. The hard-coded
+// geolocation table is only used when the regions can't be read.
+func publicCloudGetFailoverIpRegions(c *Config, projectId string, ip PublicCloudFailoverIp) []string {
+	regions, err := c.PublicCloudRegions(projectId)
+	if err != nil {
+		log.Printf("[WARN] couldn't read regions of project %s, using defaults for failover ip %s: %s", projectId, ip.IP, err)
+		return publicCloudFailoverIpDefaultRegions[ip.GeoLocation]
+	}
+
+	eligible := []string{}
+	for _, region := range regions {
+		if ip.ContinentCode != "" && region.ContinentCode == ip.ContinentCode {
+			eligible = append(eligible, region.Name)
+		}
+	}
+
+	if len(eligible) == 0 {
+		log.Printf("[WARN] no region of project %s matches failover ip %s continent %q, using defaults", projectId, ip.IP, ip.ContinentCode)
+		return publicCloudFailoverIpDefaultRegions[ip.GeoLocation]
+	}
+
+	return eligible
+}
+
+// publicCloudFailoverIpDefaultRegions maps failover ips geolocations to the
+// regions they could be routed to before region metadata was exposed.
+var publicCloudFailoverIpDefaultRegions = map[string][]string{
+	"BE": {"GRA1", "SBG1"},
+	"CA": {"BHS1"},
+	"CZ": {"GRA1", "SBG1"},
+	"FI": {"GRA1", "SBG1"},
+	"FR": {"GRA1", "SBG1"},
+	"DE": {"GRA1", "SBG1"},
+	"IE": {"GRA1", "SBG1"},
+	"IT": {"GRA1", "SBG1"},
+	"LT": {"GRA1", "SBG1"},
+	"NL": {"GRA1", "SBG1"},
+	"PL": {"GRA1", "SBG1"},
+	"PT": {"GRA1", "SBG1"},
+	"ES": {"GRA1", "SBG1"},
+	"UK": {"GRA1", "SBG1"},
+	"US": {"BHS1"},
 }
@@ -0,0 +1,160 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"reflect"
+	"testing"
+)
+
+func TestPublicCloudFailoverIpRead_notFound(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudFailoverIp().Schema, map[string]interface{}{
+		"project_id":  ovhtest.ProjectId,
+		"instance_id": "instance-gra1-a",
+		"ip_id":       "failover-ip-deleted",
+	})
+	d.SetId("failover-ip-deleted")
+
+	if err := resourcePublicCloudFailoverIpRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("failover ip should have been removed from state, got id %s", d.Id())
+	}
+}
+
+func testFakeFailoverIpRoutedTo(t *testing.T, config *Config, instanceId string) {
+	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/failover-ip-fr", ovhtest.ProjectId)
+	ip := PublicCloudFailoverIp{}
+
+	if err := config.OVHClient.Post(endpoint+"/attach", map[string]string{"instanceId": instanceId}, &ip); err != nil {
+		t.Fatalf("couldn't attach failover ip: %s", err)
+	}
+	if err := config.OVHClient.Get(endpoint, &ip); err != nil {
+		t.Fatalf("couldn't read failover ip: %s", err)
+	}
+}
+
+func testFakeFailoverIpRouting(t *testing.T, config *Config) string {
+	endpoint := fmt.Sprintf("/cloud/project/%s/ip/failover/failover-ip-fr", ovhtest.ProjectId)
+	ip := PublicCloudFailoverIp{}
+
+	if err := config.OVHClient.Get(endpoint, &ip); err != nil {
+		t.Fatalf("couldn't read failover ip: %s", err)
+	}
+	return ip.RoutedTo
+}
+
+func TestPublicCloudFailoverIpDelete(t *testing.T) {
+	cases := []struct {
+		name       string
+		routedTo   string
+		fallbackId string
+		expected   string
+	}{
+		{"park", "instance-gra1-a", "", ""},
+		{"fallback", "instance-gra1-a", "instance-gra1-b", "instance-gra1-b"},
+		{"moved out of band", "instance-gra1-b", "", "instance-gra1-b"},
+	}
+
+	for _, c := range cases {
+		config, srv := testFakeConfig(t)
+		testFakeFailoverIpRoutedTo(t, config, c.routedTo)
+
+		d := schema.TestResourceDataRaw(t, resourcePublicCloudFailoverIp().Schema, map[string]interface{}{
+			"project_id":           ovhtest.ProjectId,
+			"instance_id":          "instance-gra1-a",
+			"ip_id":                "failover-ip-fr",
+			"fallback_instance_id": c.fallbackId,
+		})
+		d.SetId("failover-ip-fr")
+
+		if err := resourcePublicCloudFailoverIpDelete(d, config); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+
+		if routedTo := testFakeFailoverIpRouting(t, config); routedTo != c.expected {
+			t.Errorf("%s: expected failover ip to be routed to %q, got %q", c.name, c.expected, routedTo)
+		}
+
+		if d.Id() != "" {
+			t.Errorf("%s: failover ip should have been removed from state, got id %s", c.name, d.Id())
+		}
+
+		srv.Close()
+	}
+}
+
+func TestPublicCloudFailoverIpUpdate_move(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+	testFakeFailoverIpRoutedTo(t, config, "instance-gra1-a")
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudFailoverIp().Schema, map[string]interface{}{
+		"project_id":  ovhtest.ProjectId,
+		"instance_id": "instance-gra1-b",
+		"ip_id":       "failover-ip-fr",
+	})
+	d.SetId("failover_ip_failover-ip-fr-instance_instance-gra1-a")
+
+	if err := resourcePublicCloudFailoverIpUpdate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if routedTo := testFakeFailoverIpRouting(t, config); routedTo != "instance-gra1-b" {
+		t.Errorf("expected failover ip to be routed to instance-gra1-b, got %q", routedTo)
+	}
+
+	if d.Id() != "failover-ip-fr" {
+		t.Errorf("expected id to be migrated to failover-ip-fr, got %s", d.Id())
+	}
+}
+
+func TestPublicCloudGetFailoverIpRegions(t *testing.T) {
+	config, srv := testFakeConfig(t)
+
+	ip := PublicCloudFailoverIp{IP: "198.51.100.10", ContinentCode: "EU", GeoLocation: "FR"}
+	expected := []string{"DE1", "GRA1", "GRA7", "SBG5", "UK1", "WAW1"}
+
+	regions := publicCloudGetFailoverIpRegions(config, ovhtest.ProjectId, ip)
+	if !reflect.DeepEqual(regions, expected) {
+		t.Errorf("expected regions %v, got %v", expected, regions)
+	}
+
+	// regions are cached for the rest of the provider run
+	srv.Close()
+	regions = publicCloudGetFailoverIpRegions(config, ovhtest.ProjectId, ip)
+	if !reflect.DeepEqual(regions, expected) {
+		t.Errorf("expected cached regions %v, got %v", expected, regions)
+	}
+
+	// defaults are used when regions can't be read
+	regions = publicCloudGetFailoverIpRegions(config, "unknown-project", ip)
+	if !reflect.DeepEqual(regions, []string{"GRA1", "SBG1"}) {
+		t.Errorf("expected default regions, got %v", regions)
+	}
+}
+
+func TestPublicCloudFailoverIpImportState(t *testing.T) {
+	r := resourcePublicCloudFailoverIp()
+
+	d := r.Data(nil)
+	d.SetId("my-project/failover-ip-fr")
+	if _, err := r.Importer.State(d, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != "failover-ip-fr" || d.Get("ip_id").(string) != "failover-ip-fr" || d.Get("project_id").(string) != "my-project" {
+		t.Errorf("expected failover ip failover-ip-fr of project my-project, got %s of %s", d.Get("ip_id"), d.Get("project_id"))
+	}
+
+	d = r.Data(nil)
+	d.SetId("my-project/failover-ip-fr/extra")
+	if _, err := r.Importer.State(d, nil); err == nil {
+		t.Errorf("expected an error for a malformed id")
+	}
+}
@@ -4,12 +4,22 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/ovh/go-ovh/ovh"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
 	"log"
 	"os"
-	"time"
+	"regexp"
+	"strings"
+	"sync"
 )
 
+var pcpnAccessRules = []AccessRule{
+	{"POST", "/cloud/project/*/network/private"},
+	{"GET", "/cloud/project/*/network/private/*"},
+	{"PUT", "/cloud/project/*/network/private/*"},
+	{"DELETE", "/cloud/project/*/network/private/*"},
+	{"POST", "/cloud/project/*/network/private/*/region"},
+}
+
 func resourcePublicCloudPrivateNetwork() *schema.Resource {
 	return &schema.Resource{
 		Create: resourcePublicCloudPrivateNetworkCreate,
@@ -17,10 +27,14 @@ func resourcePublicCloudPrivateNetwork() *schema.Resource {
 		Update: resourcePublicCloudPrivateNetworkUpdate,
 		Delete: resourcePublicCloudPrivateNetworkDelete,
 		Importer: &schema.ResourceImporter{
-			State: func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
-				return []*schema.ResourceData{d}, nil
-			},
+			State: resourcePublicCloudPrivateNetworkImportState,
+		},
+		CustomizeDiff: resourcePublicCloudPrivateNetworkCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(unsetTimeout),
+			Update: schema.DefaultTimeout(unsetTimeout),
+			Delete: schema.DefaultTimeout(unsetTimeout),
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -45,7 +59,6 @@ func resourcePublicCloudPrivateNetwork() *schema.Resource {
 				Type:     schema.TypeSet,
 				Optional: true,
 				Computed: true,
-				ForceNew: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
 				Set:      schema.HashString,
 			},
@@ -83,6 +96,114 @@ func resourcePublicCloudPrivateNetwork() *schema.Resource {
 	}
 }
 
+// pcpnImportID matches import ids of the form project_id/network_id
+var pcpnImportID = regexp.MustCompile("^([^/]+)/([^/]+)$")
+
+// resourcePublicCloudPrivateNetworkImportState imports networks from
+// project_id/network_id ids. A bare network_id is still accepted, the
+// project being read from OVH_PROJECT_ID.
+func resourcePublicCloudPrivateNetworkImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if params := pcpnImportID.FindStringSubmatch(d.Id()); params != nil {
+		d.Set("project_id", params[1])
+		d.SetId(params[2])
+		return []*schema.ResourceData{d}, nil
+	}
+
+	if strings.Contains(d.Id(), "/") {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id nor network id from id %q, expected project_id/network_id", d.Id())
+	}
+
+	d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourcePublicCloudPrivateNetworkCustomizeDiff replaces the network when
+// regions are removed, as a network can't be disabled in a region. Added
+// regions are enabled in place.
+func resourcePublicCloudPrivateNetworkCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	config, _ := meta.(*Config)
+	if !d.HasChange("regions") {
+		if config != nil {
+			config.privateNetworkWalked(d.Id(), pcpnPlanned)
+		}
+		return nil
+	}
+	if config != nil {
+		config.privateNetworkWalked(d.Id(), pcpnRegionsChanging)
+	}
+
+	if !d.NewValueKnown("regions") {
+		return nil
+	}
+
+	o, n := d.GetChange("regions")
+	removed := o.(*schema.Set).Difference(n.(*schema.Set))
+	if removed.Len() == 0 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Regions %v removed from private network %s, it must be replaced", removed.List(), d.Id())
+	return d.ForceNew("regions")
+}
+
+// pcpnWalkState is how far terraform got with a private network in the
+// graph walk the provider is configured for.
+type pcpnWalkState int
+
+const (
+	pcpnUnseen pcpnWalkState = iota
+	// pcpnRefreshed networks were only read, as in the refresh walk which
+	// plans the resources to create before any existing one is planned.
+	pcpnRefreshed
+	// pcpnPlanned networks were planned with their regions unchanged.
+	pcpnPlanned
+	// pcpnRegionsChanging networks were planned with their regions changing.
+	pcpnRegionsChanging
+)
+
+// pcpnWalk records the walk state of the private networks managed by the
+// configuration, networks being read or planned before the subnets
+// referencing them.
+type pcpnWalk struct {
+	sync.Mutex
+	networks map[string]pcpnWalkState
+}
+
+func newPcpnWalk() *pcpnWalk {
+	return &pcpnWalk{
+		networks: map[string]pcpnWalkState{},
+	}
+}
+
+// privateNetworkWalked records that the private network reached state,
+// states only ever moving forward.
+func (c *Config) privateNetworkWalked(networkId string, state pcpnWalkState) {
+	if c.privateNetworkWalk == nil {
+		return
+	}
+
+	c.privateNetworkWalk.Lock()
+	defer c.privateNetworkWalk.Unlock()
+	if state > c.privateNetworkWalk.networks[networkId] {
+		c.privateNetworkWalk.networks[networkId] = state
+	}
+}
+
+// privateNetworkWalkState returns the walk state of the private network.
+func (c *Config) privateNetworkWalkState(networkId string) pcpnWalkState {
+	if c.privateNetworkWalk == nil {
+		return pcpnUnseen
+	}
+
+	c.privateNetworkWalk.Lock()
+	defer c.privateNetworkWalk.Unlock()
+	return c.privateNetworkWalk.networks[networkId]
+}
+
 // Params
 type pcpnCreateParams struct {
 	ProjectId string   `json:"serviceName"`
@@ -100,6 +221,10 @@ type pcpnUpdateParams struct {
 	Name string `json:"name"`
 }
 
+type pcpnRegionParams struct {
+	Region string `json:"region"`
+}
+
 type pcpnRegion struct {
 	Status string `json:"status"`
 	Region string `json:"region"`
@@ -157,48 +282,117 @@ func resourcePublicCloudPrivateNetworkCreate(d *schema.ResourceData, meta interf
 		return fmt.Errorf("[ERROR] calling %s with params %s:\n\t %q", endpoint, params, err)
 	}
 
+	// set the id before waiting so that a network failing in some region
+	// is kept in state and replaced on next apply
+	d.SetId(r.Id)
+
 	log.Printf("[DEBUG] Waiting for Private Network %s:", r)
 
 	stateConf := &resource.StateChangeConf{
 		Pending:    []string{"BUILDING"},
 		Target:     []string{"ACTIVE"},
-		Refresh:    pcpnRefreshFunc(config.OVHClient, projectId, r.Id),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Refresh:    pcpnRegionsRefreshFunc(config.OVHClient, projectId, r.Id, params.Regions),
+		Timeout:    operationTimeout(d, meta, schema.TimeoutCreate),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
+	if regionErr, ok := err.(*pcpnRegionError); ok {
+		return regionErr
+	}
 	if err != nil {
 		return fmt.Errorf("[ERROR] waiting for private network (%s): %s", params, err)
 	}
 	log.Printf("[DEBUG] Created Private Network %s", r)
 
-	//set id
-	d.SetId(r.Id)
-
-	return nil
+	return resourcePublicCloudPrivateNetworkRead(d, meta)
 }
 
 func resourcePublicCloudPrivateNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(*Config)
 
 	projectId := d.Get("project_id").(string)
-	params := &pcpnUpdateParams{
-		Name: d.Get("name").(string),
+
+	d.Partial(true)
+
+	if d.HasChange("regions") {
+		o, n := d.GetChange("regions")
+		added := n.(*schema.Set).Difference(o.(*schema.Set))
+
+		regions := make([]string, 0, added.Len())
+		for _, v := range added.List() {
+			regions = append(regions, v.(string))
+		}
+
+		if err := pcpnAddRegions(d, meta, regions); err != nil {
+			return err
+		}
+		d.SetPartial("regions")
 	}
 
-	log.Printf("[DEBUG] Will update public cloud private network: %s", params)
+	if d.HasChange("name") {
+		params := &pcpnUpdateParams{
+			Name: d.Get("name").(string),
+		}
 
-	endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s", projectId, d.Id())
+		log.Printf("[DEBUG] Will update public cloud private network: %s", params)
 
-	err := config.OVHClient.Put(endpoint, params, nil)
-	if err != nil {
-		return fmt.Errorf("[ERROR] calling %s with params %s:\n\t %q", endpoint, params, err)
+		endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s", projectId, d.Id())
+
+		err := config.OVHClient.Put(endpoint, params, nil)
+		if err != nil {
+			return fmt.Errorf("[ERROR] calling %s with params %s:\n\t %q", endpoint, params, err)
+		}
+		d.SetPartial("name")
 	}
 
+	d.Partial(false)
+
 	log.Printf("[DEBUG] Updated Public cloud %s Private Network %s:", projectId, d.Id())
 
+	return resourcePublicCloudPrivateNetworkRead(d, meta)
+}
+
+// pcpnAddRegions enables the private network in regions and waits for
+// every one of them to be ACTIVE.
+func pcpnAddRegions(d *schema.ResourceData, meta interface{}, regions []string) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+
+	if len(regions) == 0 {
+		return nil
+	}
+
+	for _, region := range regions {
+		params := &pcpnRegionParams{Region: region}
+
+		log.Printf("[DEBUG] Will enable public cloud private network %s in region %s", d.Id(), region)
+
+		endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s/region", projectId, d.Id())
+
+		err := config.OVHClient.Post(endpoint, params, nil)
+		if err != nil {
+			return fmt.Errorf("[ERROR] calling %s with params %s:\n\t %q", endpoint, params.Region, err)
+		}
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"BUILDING"},
+		Target:     []string{"ACTIVE"},
+		Refresh:    pcpnRegionsRefreshFunc(config.OVHClient, projectId, d.Id(), regions),
+		Timeout:    operationTimeout(d, meta, schema.TimeoutUpdate),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
+	}
+
+	_, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("[ERROR] waiting for private network %s in regions %v: %s", d.Id(), regions, err)
+	}
+
+	log.Printf("[DEBUG] Enabled Private Network %s in regions %v", d.Id(), regions)
 	return nil
 }
 
@@ -215,10 +409,11 @@ func resourcePublicCloudPrivateNetworkRead(d *schema.ResourceData, meta interfac
 
 	err := config.OVHClient.Get(endpoint, r)
 	if err != nil {
-		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+		return CheckDeleted(d, err, endpoint)
 	}
 
 	readPcpn(d, r)
+	config.privateNetworkWalked(d.Id(), pcpnRefreshed)
 
 	log.Printf("[DEBUG] Read Public Cloud Private Network %s", r)
 	return nil
@@ -264,9 +459,9 @@ func resourcePublicCloudPrivateNetworkDelete(d *schema.ResourceData, meta interf
 		Pending:    []string{"DELETING"},
 		Target:     []string{"DELETED"},
 		Refresh:    pcpnDelRefreshFunc(config.OVHClient, projectId, id),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutDelete),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -280,7 +475,7 @@ func resourcePublicCloudPrivateNetworkDelete(d *schema.ResourceData, meta interf
 	return nil
 }
 
-func pcpnExists(projectId, id string, c *ovh.Client) error {
+func pcpnExists(projectId, id string, c *OVHClient) error {
 	r := &pcpnResponse{}
 
 	log.Printf("[DEBUG] Will read public cloud private network for project: %s, id: %s", projectId, id)
@@ -296,9 +491,27 @@ func pcpnExists(projectId, id string, c *ovh.Client) error {
 	return nil
 }
 
-// AttachmentStateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
-// an Attachment Task.
-func pcpnRefreshFunc(c *ovh.Client, projectId, pcpnId string) resource.StateRefreshFunc {
+// pcpnRegionError reports the regions a private network failed to be
+// built in, along with their status.
+type pcpnRegionError struct {
+	NetworkId string
+	Regions   []*pcpnRegion
+}
+
+func (e *pcpnRegionError) Error() string {
+	failures := make([]string, 0, len(e.Regions))
+	for _, region := range e.Regions {
+		failures = append(failures, fmt.Sprintf("%s (%s)", region.Region, region.Status))
+	}
+	return fmt.Sprintf("private network %s failed in regions: %s", e.NetworkId, strings.Join(failures, ", "))
+}
+
+// pcpnRegionsRefreshFunc returns a resource.StateRefreshFunc that is used to
+// watch the regions of a private network, or all of them if regions is
+// empty. The state is ACTIVE once the network and every region are, and
+// BUILDING while any is still missing or building. Regions in any other
+// status fail with a *pcpnRegionError.
+func pcpnRegionsRefreshFunc(c *OVHClient, projectId, pcpnId string, regions []string) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := &pcpnResponse{}
 		endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s", projectId, pcpnId)
@@ -307,20 +520,49 @@ func pcpnRefreshFunc(c *ovh.Client, projectId, pcpnId string) resource.StateRefr
 			return r, "", err
 		}
 
+		statuses := make(map[string]string, len(r.Regions))
+		for _, region := range r.Regions {
+			statuses[region.Region] = region.Status
+		}
+
+		watched := regions
+		if len(watched) == 0 {
+			watched = make([]string, 0, len(r.Regions))
+			for _, region := range r.Regions {
+				watched = append(watched, region.Region)
+			}
+		}
+
+		state := r.Status
+		failed := []*pcpnRegion{}
+		for _, region := range watched {
+			switch status := statuses[region]; status {
+			case "ACTIVE":
+			case "", "BUILDING":
+				state = "BUILDING"
+			default:
+				failed = append(failed, &pcpnRegion{Region: region, Status: status})
+			}
+		}
+
+		if len(failed) > 0 {
+			return r, "", &pcpnRegionError{NetworkId: pcpnId, Regions: failed}
+		}
+
 		log.Printf("[DEBUG] Pending Private Network: %s", r)
-		return r, r.Status, nil
+		return r, state, nil
 	}
 }
 
 // AttachmentStateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
 // an Attachment Task.
-func pcpnDelRefreshFunc(c *ovh.Client, projectId, pcpnId string) resource.StateRefreshFunc {
+func pcpnDelRefreshFunc(c *OVHClient, projectId, pcpnId string) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := &pcpnResponse{}
 		endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s", projectId, pcpnId)
 		err := c.Get(endpoint, r)
 		if err != nil {
-			if err.(*ovh.APIError).Code == 404 {
+			if ovherr.IsNotFound(err) {
 				log.Printf("[DEBUG] private network id %s on project %s deleted", pcpnId, projectId)
 				return r, "DELETED", nil
 			} else {
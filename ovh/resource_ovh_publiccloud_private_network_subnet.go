@@ -3,23 +3,29 @@ package ovh
 import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/ovh/go-ovh/ovh"
 	"log"
+	"net"
 	"os"
+	"regexp"
+	"strings"
 )
 
+var pcpnsAccessRules = []AccessRule{
+	{"GET", "/cloud/project/*/network/private/*"},
+	{"GET", "/cloud/project/*/network/private/*/subnet"},
+	{"POST", "/cloud/project/*/network/private/*/subnet"},
+	{"DELETE", "/cloud/project/*/network/private/*/subnet/*"},
+}
+
 func resourcePublicCloudPrivateNetworkSubnet() *schema.Resource {
 	return &schema.Resource{
 		Create: resourcePublicCloudPrivateNetworkSubnetCreate,
 		Read:   resourcePublicCloudPrivateNetworkSubnetRead,
 		Delete: resourcePublicCloudPrivateNetworkSubnetDelete,
 		Importer: &schema.ResourceImporter{
-			State: func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
-				d.Set("network_id", os.Getenv("OVH_NETWORK_ID"))
-				return []*schema.ResourceData{d}, nil
-			},
+			State: resourcePublicCloudPrivateNetworkSubnetImportState,
 		},
+		CustomizeDiff: resourcePublicCloudPrivateNetworkSubnetCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"project_id": &schema.Schema{
@@ -40,19 +46,22 @@ func resourcePublicCloudPrivateNetworkSubnet() *schema.Resource {
 				Default:  false,
 			},
 			"start": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIPv4,
 			},
 			"end": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIPv4,
 			},
 			"network": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIPv4CIDR,
 			},
 			"region": &schema.Schema{
 				Type:     schema.TypeString,
@@ -67,7 +76,6 @@ func resourcePublicCloudPrivateNetworkSubnet() *schema.Resource {
 			},
 			"gateway_ip": &schema.Schema{
 				Type:     schema.TypeString,
-				Optional: true,
 				Computed: true,
 			},
 
@@ -119,6 +127,151 @@ func resourcePublicCloudPrivateNetworkSubnet() *schema.Resource {
 	}
 }
 
+// pcpnsImportID matches import ids of the form project_id/network_id/subnet_id
+var pcpnsImportID = regexp.MustCompile("^([^/]+)/([^/]+)/([^/]+)$")
+
+// resourcePublicCloudPrivateNetworkSubnetImportState imports subnets from
+// project_id/network_id/subnet_id ids. A bare subnet_id is still accepted,
+// the project and network being read from OVH_PROJECT_ID and OVH_NETWORK_ID.
+func resourcePublicCloudPrivateNetworkSubnetImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if params := pcpnsImportID.FindStringSubmatch(d.Id()); params != nil {
+		d.Set("project_id", params[1])
+		d.Set("network_id", params[2])
+		d.SetId(params[3])
+		return []*schema.ResourceData{d}, nil
+	}
+
+	if strings.Contains(d.Id(), "/") {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id, network id nor subnet id from id %q, expected project_id/network_id/subnet_id", d.Id())
+	}
+
+	d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
+	d.Set("network_id", os.Getenv("OVH_NETWORK_ID"))
+	return []*schema.ResourceData{d}, nil
+}
+
+func validateIPv4(v interface{}, k string) (ws []string, errors []error) {
+	if ip := net.ParseIP(v.(string)); ip == nil || ip.To4() == nil {
+		errors = append(errors, fmt.Errorf("%q must be an IPv4 address, got %q", k, v))
+	}
+	return
+}
+
+func validateIPv4CIDR(v interface{}, k string) (ws []string, errors []error) {
+	ip, _, err := net.ParseCIDR(v.(string))
+	if err != nil || ip.To4() == nil {
+		errors = append(errors, fmt.Errorf("%q must be an IPv4 CIDR such as 192.168.0.0/24, got %q", k, v))
+	}
+	return
+}
+
+func ipv4ToUint32(ip net.IP) uint32 {
+	ip = ip.To4()
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+// validatePcpnsRange checks the start-end allocation range of a subnet is
+// ordered, inside network and excludes the network and broadcast addresses.
+func validatePcpnsRange(network, start, end string) error {
+	_, ipnet, err := net.ParseCIDR(network)
+	if err != nil || ipnet.IP.To4() == nil {
+		return fmt.Errorf("network %q is not an IPv4 CIDR", network)
+	}
+
+	startIP, endIP := net.ParseIP(start), net.ParseIP(end)
+	if startIP == nil || startIP.To4() == nil {
+		return fmt.Errorf("start %q is not an IPv4 address", start)
+	}
+	if endIP == nil || endIP.To4() == nil {
+		return fmt.Errorf("end %q is not an IPv4 address", end)
+	}
+
+	if !ipnet.Contains(startIP) {
+		return fmt.Errorf("start %s is outside of network %s", start, ipnet)
+	}
+	if !ipnet.Contains(endIP) {
+		return fmt.Errorf("end %s is outside of network %s", end, ipnet)
+	}
+
+	first, last := ipv4ToUint32(startIP), ipv4ToUint32(endIP)
+	if first > last {
+		return fmt.Errorf("start %s is after end %s", start, end)
+	}
+
+	networkAddress := ipv4ToUint32(ipnet.IP)
+	broadcastAddress := networkAddress | ^ipv4ToUint32(net.IP(ipnet.Mask))
+	if first == networkAddress {
+		return fmt.Errorf("range %s-%s includes the network address of %s", start, end, ipnet)
+	}
+	if last == broadcastAddress {
+		return fmt.Errorf("range %s-%s includes the broadcast address of %s", start, end, ipnet)
+	}
+
+	return nil
+}
+
+// resourcePublicCloudPrivateNetworkSubnetCustomizeDiff validates at plan
+// time what the API would otherwise only refuse on apply: the allocation
+// range and the region, which must be one of the network's. Values only
+// known on apply are left for the API to check. The region of a network
+// whose regions change in the same plan is checked on create, once the
+// network is updated, and it isn't checked on refresh, before the network
+// is planned.
+func resourcePublicCloudPrivateNetworkSubnetCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	changed := d.Id() == ""
+	for _, k := range []string{"network", "start", "end", "region"} {
+		changed = changed || d.HasChange(k)
+	}
+	if !changed {
+		return nil
+	}
+
+	if d.NewValueKnown("network") && d.NewValueKnown("start") && d.NewValueKnown("end") {
+		err := validatePcpnsRange(d.Get("network").(string), d.Get("start").(string), d.Get("end").(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	config, ok := meta.(*Config)
+	if !ok || !d.NewValueKnown("project_id") || !d.NewValueKnown("network_id") || !d.NewValueKnown("region") {
+		return nil
+	}
+
+	networkId := d.Get("network_id").(string)
+	switch config.privateNetworkWalkState(networkId) {
+	case pcpnRefreshed:
+		log.Printf("[DEBUG] Private network %s isn't planned yet, region %s will be checked on plan", networkId, d.Get("region"))
+		return nil
+	case pcpnRegionsChanging:
+		log.Printf("[DEBUG] Regions of private network %s change in this plan, region %s will be checked on create", networkId, d.Get("region"))
+		return nil
+	}
+
+	return pcpnsCheckRegion(config, d.Get("project_id").(string), networkId, d.Get("region").(string))
+}
+
+// pcpnsCheckRegion checks the network is enabled in region.
+func pcpnsCheckRegion(config *Config, projectId, networkId, region string) error {
+	r := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s", projectId, networkId)
+
+	err := config.OVHClient.Get(endpoint, r)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+	}
+
+	regions := make([]string, 0, len(r.Regions))
+	for _, networkRegion := range r.Regions {
+		if networkRegion.Region == region {
+			return nil
+		}
+		regions = append(regions, networkRegion.Region)
+	}
+
+	return fmt.Errorf("region %s is not one of the regions of private network %s: %s", region, networkId, strings.Join(regions, ", "))
+}
+
 // Params
 type pcpnsCreateParams struct {
 	ProjectId string `json:"serviceName"`
@@ -179,9 +332,24 @@ func resourcePublicCloudPrivateNetworkSubnetCreate(d *schema.ResourceData, meta
 
 	log.Printf("[DEBUG] Will create public cloud private network subnet: %s", params)
 
+	if err := pcpnsCheckRegion(config, projectId, networkId, params.Region); err != nil {
+		return err
+	}
+
 	endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s/subnet", projectId, networkId)
 
-	err := config.OVHClient.Post(endpoint, params, r)
+	existing := []*pcpnsResponse{}
+	err := config.OVHClient.Get(endpoint, &existing)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+	}
+
+	if conflicts := pcpnsOverlaps(existing, params.Network, params.Region, params.Start, params.End); len(conflicts) > 0 {
+		return fmt.Errorf("[ERROR] subnet %s (%s-%s) in region %s overlaps existing subnets of private network %s:\n\t%s",
+			params.Network, params.Start, params.End, params.Region, networkId, strings.Join(conflicts, "\n\t"))
+	}
+
+	err = config.OVHClient.Post(endpoint, params, r)
 	if err != nil {
 		return fmt.Errorf("[ERROR] calling %s with params %s:\n\t %q", endpoint, params, err)
 	}
@@ -208,7 +376,13 @@ func resourcePublicCloudPrivateNetworkSubnetRead(d *schema.ResourceData, meta in
 
 	err := config.OVHClient.Get(endpoint, &r)
 	if err != nil {
-		return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+		return CheckDeleted(d, err, endpoint)
+	}
+
+	if findPcpns(r, d.Id()) == nil {
+		log.Printf("[WARN] subnet %s not found in %s, removing it from state", d.Id(), endpoint)
+		d.SetId("")
+		return nil
 	}
 
 	err = readPcpns(d, r)
@@ -220,6 +394,46 @@ func resourcePublicCloudPrivateNetworkSubnetRead(d *schema.ResourceData, meta in
 	return nil
 }
 
+// pcpnsOverlaps describes the ip pools of subnets in region whose network
+// or allocation range overlaps network or the start-end range.
+func pcpnsOverlaps(subnets []*pcpnsResponse, network, region, start, end string) []string {
+	_, ipnet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil
+	}
+	startIP, endIP := net.ParseIP(start).To4(), net.ParseIP(end).To4()
+
+	conflicts := []string{}
+	for _, subnet := range subnets {
+		for _, pool := range subnet.IPPools {
+			if pool.Region != region {
+				continue
+			}
+
+			poolNetwork := pool.Network
+			if poolNetwork == "" {
+				poolNetwork = subnet.Cidr
+			}
+
+			overlaps := false
+			if _, poolIPNet, err := net.ParseCIDR(poolNetwork); err == nil {
+				overlaps = ipnet.Contains(poolIPNet.IP) || poolIPNet.Contains(ipnet.IP)
+			}
+
+			poolStart, poolEnd := net.ParseIP(pool.Start), net.ParseIP(pool.End)
+			if startIP != nil && endIP != nil && poolStart.To4() != nil && poolEnd.To4() != nil {
+				overlaps = overlaps || (ipv4ToUint32(startIP) <= ipv4ToUint32(poolEnd) && ipv4ToUint32(poolStart) <= ipv4ToUint32(endIP))
+			}
+
+			if overlaps {
+				conflicts = append(conflicts, fmt.Sprintf("%s: %s (%s-%s)", subnet.Id, poolNetwork, pool.Start, pool.End))
+			}
+		}
+	}
+
+	return conflicts
+}
+
 func findPcpns(rs []*pcpnsResponse, id string) *pcpnsResponse {
 	for i := range rs {
 		if rs[i].Id == id {
@@ -249,13 +463,28 @@ func readPcpns(d *schema.ResourceData, rs []*pcpnsResponse) error {
 		ippools = append(ippools, ippool)
 	}
 
-	d.Set("network", ippools[0]["network"])
-	d.Set("region", ippools[0]["region"])
-	d.Set("dhcp", ippools[0]["dhcp"])
-	d.Set("start", ippools[0]["start"])
-	d.Set("end", ippools[0]["end"])
 	d.Set("ip_pools", ippools)
 
+	if len(r.IPPools) == 0 {
+		return fmt.Errorf("[ERROR] subnet %s has no ip pool", r.Id)
+	}
+
+	// start and end keep the pool they were set to, whatever its position
+	// in ipPools, every pool being read in ip_pools. The first pool is only
+	// picked on import or when the configured one is gone.
+	primary := 0
+	for i := range r.IPPools {
+		if r.IPPools[i].Start == d.Get("start").(string) && r.IPPools[i].End == d.Get("end").(string) {
+			primary = i
+		}
+	}
+
+	d.Set("network", r.IPPools[primary].Network)
+	d.Set("region", r.IPPools[primary].Region)
+	d.Set("dhcp", r.IPPools[primary].Dhcp)
+	d.Set("start", r.IPPools[primary].Start)
+	d.Set("end", r.IPPools[primary].End)
+
 	if r.GatewayIp == "" {
 		d.Set("no_gateway", true)
 	} else {
@@ -275,7 +504,7 @@ func resourcePublicCloudPrivateNetworkSubnetDelete(d *schema.ResourceData, meta
 
 	log.Printf("[DEBUG] Will delete public cloud private network subnet for project: %s, network: %s, id: %s", projectId, networkId, id)
 
-	endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s/subnet/%s", projectId, id, id)
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private/%s/subnet/%s", projectId, networkId, id)
 
 	err := config.OVHClient.Delete(endpoint, nil)
 	if err != nil {
@@ -288,7 +517,7 @@ func resourcePublicCloudPrivateNetworkSubnetDelete(d *schema.ResourceData, meta
 	return nil
 }
 
-func pcpnsExists(projectId, networkId, id string, c *ovh.Client) error {
+func pcpnsExists(projectId, networkId, id string, c *OVHClient) error {
 	r := []*pcpnsResponse{}
 
 	log.Printf("[DEBUG] Will read public cloud private network subnet for project: %s, network: %s, id: %s", projectId, networkId, id)
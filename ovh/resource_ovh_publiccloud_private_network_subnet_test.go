@@ -3,12 +3,16 @@ package ovh
 import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
-var testAccPublicCloudPrivateNetworkSubnetConfig = fmt.Sprintf(`
+const testAccPublicCloudPrivateNetworkSubnetConfigTemplate = `
 resource "ovh_vrack_publiccloud_attachment" "attach" {
   vrack_id   = "%s"
 	project_id = "%s"
@@ -31,7 +35,11 @@ resource "ovh_publiccloud_private_network_subnet" "subnet" {
   dhcp       = true
   no_gateway = false
 }
-`, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+`
+
+func testAccPublicCloudPrivateNetworkSubnetConfig() string {
+	return fmt.Sprintf(testAccPublicCloudPrivateNetworkSubnetConfigTemplate, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+}
 
 func TestAccPublicCloudPrivateNetworkSubnet_basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
@@ -40,7 +48,7 @@ func TestAccPublicCloudPrivateNetworkSubnet_basic(t *testing.T) {
 		CheckDestroy: testAccCheckPublicCloudPrivateNetworkSubnetDestroy,
 		Steps: []resource.TestStep{
 			resource.TestStep{
-				Config: testAccPublicCloudPrivateNetworkSubnetConfig,
+				Config: testAccPublicCloudPrivateNetworkSubnetConfig(),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckVRackPublicCloudAttachmentExists("ovh_vrack_publiccloud_attachment.attach", t),
 					testAccCheckPublicCloudPrivateNetworkExists("ovh_publiccloud_private_network.network", t),
@@ -51,6 +59,57 @@ func TestAccPublicCloudPrivateNetworkSubnet_basic(t *testing.T) {
 	})
 }
 
+const testAccPublicCloudPrivateNetworkSubnetAddedRegionConfigTemplate = `
+resource "ovh_vrack_publiccloud_attachment" "attach" {
+  vrack_id   = "%s"
+  project_id = "%s"
+}
+
+resource "ovh_publiccloud_private_network" "network" {
+  project_id = "${ovh_vrack_publiccloud_attachment.attach.project_id}"
+  vlan_id    = 0
+  name       = "terraform_testacc_private_net"
+  regions    = [%s]
+}
+%s`
+
+const testAccPublicCloudPrivateNetworkSubnetAddedRegionSubnet = `
+resource "ovh_publiccloud_private_network_subnet" "subnet" {
+  project_id = "${ovh_publiccloud_private_network.network.project_id}"
+  network_id = "${ovh_publiccloud_private_network.network.id}"
+  region     = "WAW1"
+  start      = "192.168.168.100"
+  end        = "192.168.168.200"
+  network    = "192.168.168.0/24"
+}
+`
+
+func testAccPublicCloudPrivateNetworkSubnetAddedRegionConfig(regions, subnet string) string {
+	return fmt.Sprintf(testAccPublicCloudPrivateNetworkSubnetAddedRegionConfigTemplate, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"), regions, subnet)
+}
+
+// A subnet can be created in a region the network is enabled in by the
+// same apply.
+func TestAccPublicCloudPrivateNetworkSubnet_addedNetworkRegion(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccCheckPublicCloudPrivateNetworkSubnetPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPublicCloudPrivateNetworkSubnetDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublicCloudPrivateNetworkSubnetAddedRegionConfig(`"GRA1"`, ""),
+			},
+			resource.TestStep{
+				Config: testAccPublicCloudPrivateNetworkSubnetAddedRegionConfig(`"GRA1", "WAW1"`, testAccPublicCloudPrivateNetworkSubnetAddedRegionSubnet),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPublicCloudPrivateNetworkSubnetExists("ovh_publiccloud_private_network_subnet.subnet", t),
+					resource.TestCheckResourceAttr("ovh_publiccloud_private_network_subnet.subnet", "region", "WAW1"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckPublicCloudPrivateNetworkSubnetPreCheck(t *testing.T) {
 	testAccPreCheck(t)
 	testAccCheckPublicCloudExists(t)
@@ -96,3 +155,367 @@ func testAccCheckPublicCloudPrivateNetworkSubnetDestroy(s *terraform.State) erro
 	}
 	return nil
 }
+
+func TestPublicCloudPrivateNetworkSubnetRead_notFound(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+
+	for _, networkId := range []string{network.Id, "pn-000000_0"} {
+		d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{
+			"project_id": ovhtest.ProjectId,
+			"network_id": networkId,
+			"region":     "GRA1",
+			"start":      "192.168.168.100",
+			"end":        "192.168.168.200",
+			"network":    "192.168.168.0/24",
+		})
+		d.SetId("subnet-0")
+
+		if err := resourcePublicCloudPrivateNetworkSubnetRead(d, config); err != nil {
+			t.Fatalf("network %s: unexpected error: %s", networkId, err)
+		}
+
+		if d.Id() != "" {
+			t.Fatalf("network %s: subnet should have been removed from state, got id %s", networkId, d.Id())
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetDelete(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := config.OVHClient.Get(endpoint+"/"+network.Id, network); err != nil {
+			t.Fatalf("couldn't read private network: %s", err)
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"network_id": network.Id,
+		"region":     "GRA1",
+		"start":      "192.168.168.100",
+		"end":        "192.168.168.200",
+		"network":    "192.168.168.0/24",
+	})
+	if err := resourcePublicCloudPrivateNetworkSubnetCreate(d, config); err != nil {
+		t.Fatalf("couldn't create subnet: %s", err)
+	}
+	id := d.Id()
+
+	if err := resourcePublicCloudPrivateNetworkSubnetDelete(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != "" {
+		t.Errorf("subnet should have been removed from state, got id %s", d.Id())
+	}
+	if err := pcpnsExists(ovhtest.ProjectId, network.Id, id, config.OVHClient); err == nil {
+		t.Errorf("expected subnet %s of network %s to be deleted", id, network.Id)
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetImportState(t *testing.T) {
+	r := resourcePublicCloudPrivateNetworkSubnet()
+
+	d := r.Data(nil)
+	d.SetId("my-project/pn-1234_0/subnet-1")
+	if _, err := r.Importer.State(d, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != "subnet-1" || d.Get("project_id").(string) != "my-project" || d.Get("network_id").(string) != "pn-1234_0" {
+		t.Errorf("expected subnet subnet-1 of network pn-1234_0 of project my-project, got %s of %s of %s", d.Id(), d.Get("network_id"), d.Get("project_id"))
+	}
+
+	d = r.Data(nil)
+	d.SetId("my-project/subnet-1")
+	if _, err := r.Importer.State(d, nil); err == nil {
+		t.Errorf("expected an error for a malformed id")
+	}
+}
+
+func TestValidatePcpnsRange(t *testing.T) {
+	cases := []struct {
+		name    string
+		network string
+		start   string
+		end     string
+		valid   bool
+	}{
+		{"valid", "192.168.168.0/24", "192.168.168.100", "192.168.168.200", true},
+		{"whole network", "192.168.168.0/24", "192.168.168.1", "192.168.168.254", true},
+		{"single address", "192.168.168.0/24", "192.168.168.10", "192.168.168.10", true},
+		{"invalid network", "192.168.168.0", "192.168.168.100", "192.168.168.200", false},
+		{"ipv6 network", "2001:db8::/64", "2001:db8::1", "2001:db8::ff", false},
+		{"invalid start", "192.168.168.0/24", "192.168.168", "192.168.168.200", false},
+		{"invalid end", "192.168.168.0/24", "192.168.168.100", "end", false},
+		{"start outside network", "192.168.168.0/24", "192.168.167.100", "192.168.168.200", false},
+		{"end outside network", "192.168.168.0/24", "192.168.168.100", "192.168.169.1", false},
+		{"unordered", "192.168.168.0/24", "192.168.168.200", "192.168.168.100", false},
+		{"network address", "192.168.168.0/24", "192.168.168.0", "192.168.168.200", false},
+		{"broadcast address", "192.168.168.0/24", "192.168.168.100", "192.168.168.255", false},
+		{"non aligned network", "192.168.168.10/28", "192.168.168.0", "192.168.168.14", false},
+	}
+
+	for _, c := range cases {
+		err := validatePcpnsRange(c.network, c.start, c.end)
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestValidateIPv4(t *testing.T) {
+	cases := []struct {
+		validate func(interface{}, string) ([]string, []error)
+		value    string
+		valid    bool
+	}{
+		{validateIPv4, "192.168.168.1", true},
+		{validateIPv4, "192.168.168", false},
+		{validateIPv4, "2001:db8::1", false},
+		{validateIPv4CIDR, "192.168.168.0/24", true},
+		{validateIPv4CIDR, "192.168.168.0", false},
+		{validateIPv4CIDR, "2001:db8::/64", false},
+	}
+
+	for _, c := range cases {
+		_, errs := c.validate(c.value, "key")
+		if c.valid && len(errs) > 0 {
+			t.Errorf("%s: unexpected errors: %v", c.value, errs)
+		}
+		if !c.valid && len(errs) == 0 {
+			t.Errorf("%s: expected an error", c.value)
+		}
+	}
+}
+
+func testPcpnsResourceConfig(networkId, region string) *terraform.ResourceConfig {
+	return terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"network_id": networkId,
+		"region":     region,
+		"start":      "192.168.168.100",
+		"end":        "192.168.168.200",
+		"network":    "192.168.168.0/24",
+	})
+}
+
+func TestPublicCloudPrivateNetworkSubnetDiff_region(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+
+	r := resourcePublicCloudPrivateNetworkSubnet()
+	for region, valid := range map[string]bool{"GRA1": true, "BHS1": false} {
+		_, err := r.Diff(nil, testPcpnsResourceConfig(network.Id, region), config)
+		if valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", region, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "region BHS1")) {
+			t.Errorf("%s: expected an error for a region the network isn't enabled in, got %v", region, err)
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetCreate_region(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"network_id": network.Id,
+		"region":     "BHS1",
+		"start":      "192.168.168.100",
+		"end":        "192.168.168.200",
+		"network":    "192.168.168.0/24",
+	})
+
+	err := resourcePublicCloudPrivateNetworkSubnetCreate(d, config)
+	if err == nil || !strings.Contains(err.Error(), "region BHS1") {
+		t.Errorf("expected an error for a region the network isn't enabled in, got %v", err)
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetCreate_addedNetworkRegion(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "my_network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+
+	// Both resources are planned before the network is enabled in WAW1.
+	rn := resourcePublicCloudPrivateNetwork()
+	networkState := testPcpnInstanceState(network.Id, "GRA1")
+	networkDiff, err := rn.Diff(networkState, testPcpnResourceConfig("GRA1", "WAW1"), config)
+	if err != nil {
+		t.Fatalf("unexpected error planning the network: %s", err)
+	}
+
+	rs := resourcePublicCloudPrivateNetworkSubnet()
+	subnetDiff, err := rs.Diff(nil, testPcpnsResourceConfig(network.Id, "WAW1"), config)
+	if err != nil {
+		t.Fatalf("unexpected error planning the subnet: %s", err)
+	}
+
+	if _, err := rn.Apply(networkState, networkDiff, config); err != nil {
+		t.Fatalf("unexpected error updating the network: %s", err)
+	}
+
+	state, err := rs.Apply(nil, subnetDiff, config)
+	if err != nil {
+		t.Fatalf("unexpected error creating the subnet: %s", err)
+	}
+	if state.ID == "" || state.Attributes["region"] != "WAW1" {
+		t.Errorf("expected a subnet in WAW1, got %v", state)
+	}
+}
+
+func TestPcpnsOverlaps(t *testing.T) {
+	subnets := []*pcpnsResponse{
+		{
+			Id:   "subnet-gra1",
+			Cidr: "192.168.168.0/24",
+			IPPools: []*IPPool{
+				{Network: "192.168.168.0/24", Region: "GRA1", Start: "192.168.168.100", End: "192.168.168.200"},
+			},
+		},
+		{
+			Id:   "subnet-bhs1",
+			Cidr: "10.0.0.0/16",
+			IPPools: []*IPPool{
+				{Network: "10.0.0.0/16", Region: "BHS1", Start: "10.0.1.1", End: "10.0.1.254"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		network   string
+		region    string
+		start     string
+		end       string
+		conflicts []string
+	}{
+		{"other network", "192.168.169.0/24", "GRA1", "192.168.169.100", "192.168.169.200", []string{}},
+		{"same network other region", "192.168.168.0/24", "BHS1", "192.168.168.100", "192.168.168.200", []string{}},
+		{"same network", "192.168.168.0/24", "GRA1", "192.168.168.10", "192.168.168.20", []string{"subnet-gra1: 192.168.168.0/24 (192.168.168.100-192.168.168.200)"}},
+		{"including network", "192.168.0.0/16", "GRA1", "192.168.1.1", "192.168.1.254", []string{"subnet-gra1: 192.168.168.0/24 (192.168.168.100-192.168.168.200)"}},
+		{"included network", "10.0.2.0/24", "BHS1", "10.0.2.10", "10.0.2.20", []string{"subnet-bhs1: 10.0.0.0/16 (10.0.1.1-10.0.1.254)"}},
+	}
+
+	for _, c := range cases {
+		conflicts := pcpnsOverlaps(subnets, c.network, c.region, c.start, c.end)
+		if !reflect.DeepEqual(conflicts, c.conflicts) {
+			t.Errorf("%s: expected conflicts %v, got %v", c.name, c.conflicts, conflicts)
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetCreate_overlap(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{ProjectId: ovhtest.ProjectId, Name: "network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := config.OVHClient.Get(endpoint+"/"+network.Id, network); err != nil {
+			t.Fatalf("couldn't read private network: %s", err)
+		}
+	}
+
+	subnet := func(start, end string) *schema.ResourceData {
+		return schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{
+			"project_id": ovhtest.ProjectId,
+			"network_id": network.Id,
+			"region":     "GRA1",
+			"start":      start,
+			"end":        end,
+			"network":    "192.168.168.0/24",
+		})
+	}
+
+	first := subnet("192.168.168.100", "192.168.168.200")
+	if err := resourcePublicCloudPrivateNetworkSubnetCreate(first, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err := resourcePublicCloudPrivateNetworkSubnetCreate(subnet("192.168.168.150", "192.168.168.250"), config)
+	if err == nil || !strings.Contains(err.Error(), first.Id()) {
+		t.Errorf("expected an error naming subnet %s, got %v", first.Id(), err)
+	}
+}
+
+func TestPublicCloudPrivateNetworkSubnetRead_poolsOrder(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{
+		"start": "192.168.168.100",
+		"end":   "192.168.168.200",
+	})
+	d.SetId("subnet-1")
+
+	subnets := []*pcpnsResponse{{
+		Id:        "subnet-1",
+		GatewayIp: "192.168.168.1",
+		Cidr:      "192.168.168.0/24",
+		IPPools: []*IPPool{
+			{Network: "192.168.168.0/24", Region: "GRA1", Start: "192.168.168.10", End: "192.168.168.50"},
+			{Network: "192.168.168.0/24", Region: "GRA1", Start: "192.168.168.100", End: "192.168.168.200"},
+		},
+	}}
+
+	if err := readPcpns(d, subnets); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if start, end := d.Get("start").(string), d.Get("end").(string); start != "192.168.168.100" || end != "192.168.168.200" {
+		t.Errorf("expected range 192.168.168.100-192.168.168.200 to be kept, got %s-%s", start, end)
+	}
+	if n := d.Get("ip_pools").(*schema.Set).Len(); n != 2 {
+		t.Errorf("expected 2 ip pools, got %d", n)
+	}
+
+	imported := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetworkSubnet().Schema, map[string]interface{}{})
+	imported.SetId("subnet-1")
+	if err := readPcpns(imported, subnets); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if start := imported.Get("start").(string); start != "192.168.168.10" {
+		t.Errorf("expected the first pool on import, got start %s", start)
+	}
+}
@@ -3,12 +3,15 @@ package ovh
 import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
 	"os"
+	"strconv"
 	"testing"
 )
 
-var testAccPublicCloudPrivateNetworkConfig = fmt.Sprintf(`
+const testAccPublicCloudPrivateNetworkConfigTemplate = `
 resource "ovh_vrack_publiccloud_attachment" "attach" {
   vrack_id = "%s"
 	project_id = "%s"
@@ -20,7 +23,11 @@ resource "ovh_publiccloud_private_network" "network" {
   name = "terraform_testacc_private_net"
   regions     = ["GRA1", "BHS1"]
 }
-`, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+`
+
+func testAccPublicCloudPrivateNetworkConfig() string {
+	return fmt.Sprintf(testAccPublicCloudPrivateNetworkConfigTemplate, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+}
 
 func TestAccPublicCloudPrivateNetwork_basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
@@ -29,7 +36,7 @@ func TestAccPublicCloudPrivateNetwork_basic(t *testing.T) {
 		CheckDestroy: testAccCheckPublicCloudPrivateNetworkDestroy,
 		Steps: []resource.TestStep{
 			resource.TestStep{
-				Config: testAccPublicCloudPrivateNetworkConfig,
+				Config: testAccPublicCloudPrivateNetworkConfig(),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckVRackPublicCloudAttachmentExists("ovh_vrack_publiccloud_attachment.attach", t),
 					testAccCheckPublicCloudPrivateNetworkExists("ovh_publiccloud_private_network.network", t),
@@ -80,3 +87,185 @@ func testAccCheckPublicCloudPrivateNetworkDestroy(s *terraform.State) error {
 	}
 	return nil
 }
+
+func TestPublicCloudPrivateNetworkRead_notFound(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetwork().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"name":       "deleted_out_of_band",
+	})
+	d.SetId("pn-000000_0")
+
+	if err := resourcePublicCloudPrivateNetworkRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("private network should have been removed from state, got id %s", d.Id())
+	}
+}
+
+func TestPublicCloudPrivateNetworkImportState(t *testing.T) {
+	r := resourcePublicCloudPrivateNetwork()
+
+	d := r.Data(nil)
+	d.SetId("my-project/pn-1234_0")
+	if _, err := r.Importer.State(d, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != "pn-1234_0" || d.Get("project_id").(string) != "my-project" {
+		t.Errorf("expected network pn-1234_0 of project my-project, got %s of %s", d.Id(), d.Get("project_id"))
+	}
+
+	d = r.Data(nil)
+	d.SetId("my-project/pn-1234_0/extra")
+	if _, err := r.Importer.State(d, nil); err == nil {
+		t.Errorf("expected an error for a malformed id")
+	}
+}
+
+func testPcpnInstanceState(id string, regions ...string) *terraform.InstanceState {
+	attributes := map[string]string{
+		"id":         id,
+		"project_id": ovhtest.ProjectId,
+		"name":       "my_network",
+		"vlan_id":    "0",
+		"regions.#":  strconv.Itoa(len(regions)),
+	}
+	for _, region := range regions {
+		attributes[fmt.Sprintf("regions.%d", schema.HashString(region))] = region
+	}
+	return &terraform.InstanceState{ID: id, Attributes: attributes}
+}
+
+func testPcpnResourceConfig(regions ...string) *terraform.ResourceConfig {
+	raw := []interface{}{}
+	for _, region := range regions {
+		raw = append(raw, region)
+	}
+	return terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"name":       "my_network",
+		"regions":    raw,
+	})
+}
+
+func TestPublicCloudPrivateNetworkDiff_regions(t *testing.T) {
+	r := resourcePublicCloudPrivateNetwork()
+	state := testPcpnInstanceState("pn-1234_0", "GRA1", "BHS1")
+
+	cases := []struct {
+		regions     []string
+		requiresNew bool
+	}{
+		{[]string{"GRA1", "BHS1", "WAW1"}, false},
+		{[]string{"GRA1"}, true},
+		{[]string{"GRA1", "WAW1"}, true},
+	}
+
+	for _, c := range cases {
+		diff, err := r.Diff(state, testPcpnResourceConfig(c.regions...), nil)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", c.regions, err)
+			continue
+		}
+		if diff.RequiresNew() != c.requiresNew {
+			t.Errorf("%v: expected requires new to be %v, got %v", c.regions, c.requiresNew, diff.RequiresNew())
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkUpdate_addRegion(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	network := &pcpnResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", ovhtest.ProjectId)
+	params := &pcpnCreateParams{Name: "my_network", Regions: []string{"GRA1"}}
+	if err := config.OVHClient.Post(endpoint, params, network); err != nil {
+		t.Fatalf("couldn't create private network: %s", err)
+	}
+
+	r := resourcePublicCloudPrivateNetwork()
+	state := testPcpnInstanceState(network.Id, "GRA1")
+
+	diff, err := r.Diff(state, testPcpnResourceConfig("GRA1", "WAW1"), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	state, err = r.Apply(state, diff, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if state.ID != network.Id {
+		t.Errorf("expected network %s to be kept, got %s", network.Id, state.ID)
+	}
+	if state.Attributes["regions.#"] != "2" {
+		t.Errorf("expected 2 regions, got %s", state.Attributes["regions.#"])
+	}
+
+	r2 := &pcpnResponse{}
+	if err := config.OVHClient.Get(endpoint+"/"+network.Id, r2); err != nil {
+		t.Fatalf("couldn't read private network: %s", err)
+	}
+	for _, region := range r2.Regions {
+		if region.Status != "ACTIVE" {
+			t.Errorf("expected region %s to be ACTIVE, got %s", region.Region, region.Status)
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkCreate(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetwork().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"name":       "my_network",
+		"regions":    []interface{}{"GRA1", "WAW1"},
+	})
+
+	if err := resourcePublicCloudPrivateNetworkCreate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Get("status").(string) != "ACTIVE" {
+		t.Errorf("expected the network to be read back ACTIVE, got %q", d.Get("status"))
+	}
+	for _, v := range d.Get("regions_status").(*schema.Set).List() {
+		region := v.(map[string]interface{})
+		if region["status"] != "ACTIVE" {
+			t.Errorf("expected region %s to be ACTIVE, got %s", region["region"], region["status"])
+		}
+	}
+}
+
+func TestPublicCloudPrivateNetworkCreate_regionFailure(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	srv.FailPrivateNetworkRegion("WAW1")
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudPrivateNetwork().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"name":       "my_network",
+		"regions":    []interface{}{"GRA1", "WAW1"},
+	})
+
+	err := resourcePublicCloudPrivateNetworkCreate(d, config)
+	regionErr, ok := err.(*pcpnRegionError)
+	if !ok {
+		t.Fatalf("expected a region error, got %v", err)
+	}
+
+	if len(regionErr.Regions) != 1 || regionErr.Regions[0].Region != "WAW1" || regionErr.Regions[0].Status != "ERROR" {
+		t.Errorf("expected WAW1 to be reported in ERROR, got %s", regionErr.Regions)
+	}
+	if d.Id() == "" {
+		t.Errorf("the failed network should be kept in state")
+	}
+}
@@ -4,26 +4,43 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/ovh/go-ovh/ovh"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
 	"log"
+	"net/url"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
-	"time"
+	"strings"
 )
 
+var pcuAccessRules = []AccessRule{
+	{"POST", "/cloud/project/*/user"},
+	{"GET", "/cloud/project/*/user/*"},
+	{"DELETE", "/cloud/project/*/user/*"},
+	{"POST", "/cloud/project/*/user/*/regeneratePassword"},
+	{"GET", "/cloud/project/*/user/*/openrc"},
+	{"PUT", "/cloud/project/*/user/*/role"},
+	{"GET", "/cloud/project/*/role"},
+}
+
 func resourcePublicCloudUser() *schema.Resource {
 	return &schema.Resource{
 		Create: resourcePublicCloudUserCreate,
 		Read:   resourcePublicCloudUserRead,
+		Update: resourcePublicCloudUserUpdate,
 		Delete: resourcePublicCloudUserDelete,
 
+		CustomizeDiff: resourcePublicCloudUserCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
-			State: func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
-				d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
-				resourcePublicCloudUserRegeneratePassword(d, meta)
-				return []*schema.ResourceData{d}, nil
-			},
+			State: resourcePublicCloudUserImportState,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(unsetTimeout),
+			Update: schema.DefaultTimeout(unsetTimeout),
+			Delete: schema.DefaultTimeout(unsetTimeout),
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -47,6 +64,30 @@ func resourcePublicCloudUser() *schema.Resource {
 				Computed:  true,
 				Sensitive: true,
 			},
+			"pgp_key": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validatePGPKey,
+			},
+			"encrypted_password": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"key_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"roles": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"password_rotation_keepers": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
 			"status": &schema.Schema{
 				Type:     schema.TypeString,
 				Computed: true,
@@ -55,32 +96,81 @@ func resourcePublicCloudUser() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"region": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"openstack_rc": &schema.Schema{
 				Type:     schema.TypeMap,
 				Optional: true,
 				Computed: true,
 			},
+			"openrc_content": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"clouds_yaml": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
 		},
 	}
 }
 
+// pcuImportID matches import ids of the form project_id/user_id
+var pcuImportID = regexp.MustCompile("^([^/]+)/([0-9]+)$")
+
+// resourcePublicCloudUserImportState imports users from project_id/user_id
+// ids. A bare user_id is still accepted, the project being read from
+// OVH_PROJECT_ID.
+//
+// The API never returns the password of an existing user, so it's left
+// empty in state. It's only regenerated when the provider is explicitly
+// configured to, as it breaks every client using the current one.
+func resourcePublicCloudUserImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if params := pcuImportID.FindStringSubmatch(d.Id()); params != nil {
+		d.Set("project_id", params[1])
+		d.SetId(params[2])
+	} else if strings.Contains(d.Id(), "/") {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id nor user id from id %q, expected project_id/user_id", d.Id())
+	} else {
+		d.Set("project_id", os.Getenv("OVH_PROJECT_ID"))
+	}
+
+	if config, ok := meta.(*Config); ok && config.RegeneratePasswordOnImport {
+		if err := resourcePublicCloudUserRegeneratePassword(d, meta); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
 // Params
 type pcuCreateParams struct {
-	ProjectId   string `json:"serviceName"`
-	Description string `json:"description"`
+	ProjectId   string   `json:"serviceName"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles,omitempty"`
 }
 
 func (p *pcuCreateParams) String() string {
-	return fmt.Sprintf("UserParams[projectId: %s, description:%s]", p.ProjectId, p.Description)
+	return fmt.Sprintf("UserParams[projectId: %s, description:%s, roles: %v]", p.ProjectId, p.Description, p.Roles)
+}
+
+type pcuRolesParams struct {
+	RolesIds []string `json:"rolesIds"`
 }
 
 type pcuResponse struct {
-	Id           int    `json:"id"`
-	Username     string `json:"username"`
-	Status       string `json:"status"`
-	Description  string `json:"description"`
-	Password     string `json:"password"`
-	CreationDate string `json:"creationDate"`
+	Id           int               `json:"id"`
+	Username     string            `json:"username"`
+	Status       string            `json:"status"`
+	Description  string            `json:"description"`
+	Password     string            `json:"password"`
+	CreationDate string            `json:"creationDate"`
+	Roles        []PublicCloudRole `json:"roles"`
 }
 
 func (p *pcuResponse) String() string {
@@ -98,6 +188,7 @@ func resourcePublicCloudUserCreate(d *schema.ResourceData, meta interface{}) err
 	params := &pcuCreateParams{
 		ProjectId:   projectId,
 		Description: d.Get("description").(string),
+		Roles:       pcuRoleNames(d),
 	}
 
 	r := &pcuResponse{}
@@ -120,9 +211,9 @@ func resourcePublicCloudUserCreate(d *schema.ResourceData, meta interface{}) err
 		Pending:    []string{"creating"},
 		Target:     []string{"ok"},
 		Refresh:    pcuRefreshFunc(config.OVHClient, projectId, strconv.Itoa(r.Id)),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutCreate),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -131,60 +222,167 @@ func resourcePublicCloudUserCreate(d *schema.ResourceData, meta interface{}) err
 	}
 	log.Printf("[DEBUG] Created User %s", r)
 
-	readPcu(d, r, true)
+	if err := readPcu(d, r, true); err != nil {
+		return err
+	}
 
-	openstackrc := make(map[string]string)
-	err = pcuGetOpenstackRC(projectId, d.Id(), config.OVHClient, openstackrc)
-	if err != nil {
+	if err := pcuSetOpenstackRC(d, config); err != nil {
 		return fmt.Errorf("[ERROR] Creating openstack creds for user %s: %s", d.Id(), err)
 	}
 
-	d.Set("openstack_rc", &openstackrc)
-
 	d.Partial(false)
 
 	return nil
 }
 
-var pcuOSTenantName = regexp.MustCompile("export OS_TENANT_NAME=\"?([[:alnum:]]+)\"?")
-var pcuOSTenantId = regexp.MustCompile("export OS_TENANT_ID=\"??([[:alnum:]]+)\"??")
-var pcuOSAuthURL = regexp.MustCompile("export OS_AUTH_URL=\"??([[:^space:]]+)\"??")
-var pcuOSUsername = regexp.MustCompile("export OS_USERNAME=\"?([[:alnum:]]+)\"?")
+// pcuOpenrcExport matches the variables exported by openrc files. Values
+// either are literals, optionally quoted, or default to a literal such as
+// ${OS_USER_DOMAIN_NAME:-"Default"}.
+var pcuOpenrcExport = regexp.MustCompile(`^export (OS_[A-Z_]+)=(?:\$\{OS_[A-Z_]+:-)?"?([^"$}]*)"?\}?$`)
+
+// pcuOpenrcRequired lists the variables every openrc file must set.
+var pcuOpenrcRequired = []string{"OS_AUTH_URL", "OS_TENANT_ID", "OS_TENANT_NAME", "OS_USERNAME"}
+
+// pcuParseOpenrc extracts the literal variables of an openrc file. The
+// password is read from the terminal and is never part of them.
+func pcuParseOpenrc(content string) (map[string]string, error) {
+	rc := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		if m := pcuOpenrcExport.FindStringSubmatch(strings.TrimSpace(line)); m != nil && m[2] != "" {
+			rc[m[1]] = m[2]
+		}
+	}
+
+	for _, name := range pcuOpenrcRequired {
+		if rc[name] == "" {
+			return nil, fmt.Errorf("[ERROR] couln't extract %s from content: \n\t%s", name, content)
+		}
+	}
 
-func pcuGetOpenstackRC(projectId, id string, c *ovh.Client, rc map[string]string) error {
-	log.Printf("[DEBUG] Will read public cloud user openstack rc for project: %s, id: %s", projectId, id)
+	// Keystone v3 names the tenant a project
+	rc["OS_PROJECT_ID"] = rc["OS_TENANT_ID"]
+	rc["OS_PROJECT_NAME"] = rc["OS_TENANT_NAME"]
 
-	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/openrc?region=to_be_overriden", projectId, id)
+	return rc, nil
+}
+
+// pcuGetOpenstackRC reads the Keystone v3 openrc variables of the user for
+// region. Without region, the region is left unset.
+func pcuGetOpenstackRC(projectId, id, region string, c *OVHClient) (map[string]string, error) {
+	log.Printf("[DEBUG] Will read public cloud user openstack rc for project: %s, id: %s, region: %s", projectId, id, region)
+
+	queryRegion := region
+	if queryRegion == "" {
+		queryRegion = "to_be_overriden"
+	}
+
+	endpoint := fmt.Sprintf(
+		"/cloud/project/%s/user/%s/openrc?region=%s&version=v3",
+		projectId,
+		id,
+		url.QueryEscape(queryRegion),
+	)
 
 	r := &pcuOpenstackRC{}
 
 	err := c.Get(endpoint, r)
 	if err != nil {
-		return fmt.Errorf("[ERROR] calling Get %s:\n\t %q", endpoint, err)
+		return nil, fmt.Errorf("[ERROR] calling Get %s:\n\t %q", endpoint, err)
+	}
+
+	rc, err := pcuParseOpenrc(r.Content)
+	if err != nil {
+		return nil, err
 	}
 
-	authURL := pcuOSAuthURL.FindStringSubmatch(r.Content)
-	if authURL == nil {
-		return fmt.Errorf("[ERROR] couln't extract OS_AUTH_URL from content: \n\t%s", r.Content)
+	if region == "" {
+		delete(rc, "OS_REGION_NAME")
 	}
-	tenantName := pcuOSTenantName.FindStringSubmatch(r.Content)
-	if tenantName == nil {
-		return fmt.Errorf("[ERROR] couln't extract OS_TENANT_NAME from content: \n\t%s", r.Content)
+
+	return rc, nil
+}
+
+// pcuOpenrcVariables lists the openrc variables in the order they're
+// written to openrc_content.
+var pcuOpenrcVariables = []string{
+	"OS_AUTH_URL",
+	"OS_IDENTITY_API_VERSION",
+	"OS_USER_DOMAIN_NAME",
+	"OS_PROJECT_DOMAIN_NAME",
+	"OS_PROJECT_ID",
+	"OS_PROJECT_NAME",
+	"OS_TENANT_ID",
+	"OS_TENANT_NAME",
+	"OS_USERNAME",
+	"OS_PASSWORD",
+	"OS_REGION_NAME",
+}
+
+// pcuOpenrcContent writes a non interactive openrc file. The password is
+// only part of it when it's known in clear.
+func pcuOpenrcContent(rc map[string]string, password string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/bash\n")
+	for _, name := range pcuOpenrcVariables {
+		value := rc[name]
+		if name == "OS_PASSWORD" {
+			value = password
+		}
+		if value != "" {
+			fmt.Fprintf(&b, "export %s=%s\n", name, strconv.Quote(value))
+		}
 	}
-	tenantId := pcuOSTenantId.FindStringSubmatch(r.Content)
-	if tenantId == nil {
-		return fmt.Errorf("[ERROR] couln't extract OS_TENANT_ID from content: \n\t%s", r.Content)
+	return b.String()
+}
+
+// pcuCloudsYaml writes a clouds.yaml file declaring the user as the
+// "openstack" cloud. The password is only part of it when it's known in
+// clear.
+func pcuCloudsYaml(rc map[string]string, password string) string {
+	var b strings.Builder
+	b.WriteString("clouds:\n")
+	b.WriteString("  openstack:\n")
+	b.WriteString("    auth:\n")
+
+	auth := [][2]string{
+		{"auth_url", rc["OS_AUTH_URL"]},
+		{"username", rc["OS_USERNAME"]},
+		{"password", password},
+		{"project_id", rc["OS_PROJECT_ID"]},
+		{"project_name", rc["OS_PROJECT_NAME"]},
+		{"user_domain_name", rc["OS_USER_DOMAIN_NAME"]},
+		{"project_domain_name", rc["OS_PROJECT_DOMAIN_NAME"]},
 	}
-	username := pcuOSUsername.FindStringSubmatch(r.Content)
-	if username == nil {
-		return fmt.Errorf("[ERROR] couln't extract OS_USERNAME from content: \n\t%s", r.Content)
+	for _, kv := range auth {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "      %s: %s\n", kv[0], strconv.Quote(kv[1]))
+		}
 	}
 
-	rc["OS_AUTH_URL"] = authURL[1]
-	rc["OS_TENANT_ID"] = tenantId[1]
-	rc["OS_TENANT_NAME"] = tenantName[1]
-	rc["OS_USERNAME"] = username[1]
+	if region := rc["OS_REGION_NAME"]; region != "" {
+		fmt.Fprintf(&b, "    region_name: %s\n", strconv.Quote(region))
+	}
+	b.WriteString("    interface: \"public\"\n")
+	if version := rc["OS_IDENTITY_API_VERSION"]; version != "" {
+		fmt.Fprintf(&b, "    identity_api_version: %s\n", version)
+	}
+
+	return b.String()
+}
 
+// pcuSetOpenstackRC reads the openrc of the user for its region and sets
+// openstack_rc, openrc_content and clouds_yaml from it.
+func pcuSetOpenstackRC(d *schema.ResourceData, config *Config) error {
+	rc, err := pcuGetOpenstackRC(d.Get("project_id").(string), d.Id(), d.Get("region").(string), config.OVHClient)
+	if err != nil {
+		return err
+	}
+
+	password := d.Get("password").(string)
+
+	d.Set("openstack_rc", rc)
+	d.Set("openrc_content", pcuOpenrcContent(rc, password))
+	d.Set("clouds_yaml", pcuCloudsYaml(rc, password))
 	return nil
 }
 
@@ -202,23 +400,111 @@ func resourcePublicCloudUserRead(d *schema.ResourceData, meta interface{}) error
 
 	err := config.OVHClient.Get(endpoint, r)
 	if err != nil {
-		return fmt.Errorf("[ERROR] calling Get %s:\n\t %q", endpoint, err)
+		d.Partial(false)
+		return CheckDeleted(d, err, endpoint)
 	}
 
-	readPcu(d, r, false)
+	if err := readPcu(d, r, false); err != nil {
+		return err
+	}
 
-	openstackrc := make(map[string]string)
-	err = pcuGetOpenstackRC(projectId, d.Id(), config.OVHClient, openstackrc)
-	if err != nil {
+	if err := pcuSetOpenstackRC(d, config); err != nil {
 		return fmt.Errorf("[ERROR] Reading openstack creds for user %s: %s", d.Id(), err)
 	}
-
-	d.Set("openstack_rc", &openstackrc)
 	d.Partial(false)
 	log.Printf("[DEBUG] Read Public Cloud User %s", r)
 	return nil
 }
 
+// resourcePublicCloudUserUpdate replaces the roles of the user in place and
+// regenerates the password when any of the password_rotation_keepers
+// changes, keeping the same user. Setting a first pgp_key encrypts the
+// password in state; replacing or removing the key regenerates it, as the
+// current password can't be read back to be encrypted again. Other
+// changes, such as the region, only refresh the openrc.
+func resourcePublicCloudUserUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChange("roles") {
+		if err := pcuUpdateRoles(d, meta.(*Config)); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("password_rotation_keepers") {
+		log.Printf("[DEBUG] password_rotation_keepers of public cloud user %s changed, rotating password", d.Id())
+		return resourcePublicCloudUserRegeneratePassword(d, meta)
+	}
+
+	if d.HasChange("pgp_key") {
+		oldKey, _ := d.GetChange("pgp_key")
+		password, _ := d.GetChange("password")
+		if oldKey.(string) != "" || password.(string) == "" {
+			log.Printf("[DEBUG] pgp_key of public cloud user %s changed and the password isn't known in clear, rotating password", d.Id())
+			return resourcePublicCloudUserRegeneratePassword(d, meta)
+		}
+
+		log.Printf("[DEBUG] pgp_key of public cloud user %s set, encrypting the current password", d.Id())
+		if err := pcuSetPassword(d, password.(string)); err != nil {
+			return err
+		}
+	}
+
+	return resourcePublicCloudUserRead(d, meta)
+}
+
+// pcuPasswordAttributes are the attributes changing along with the password.
+var pcuPasswordAttributes = []string{"password", "encrypted_password", "key_fingerprint", "openrc_content", "clouds_yaml"}
+
+// resourcePublicCloudUserCustomizeDiff marks the password and the
+// attributes derived from it as changing when the update will rotate it, so
+// that whatever references them is updated in the same apply.
+func resourcePublicCloudUserCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !(d.HasChange("password_rotation_keepers") || d.HasChange("pgp_key")) {
+		return nil
+	}
+
+	for _, k := range pcuPasswordAttributes {
+		if err := d.SetNewComputed(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pcuRoleNames(d *schema.ResourceData) []string {
+	roles := []string{}
+	for _, role := range d.Get("roles").(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// pcuUpdateRoles replaces the roles of the user with the configured ones,
+// an empty rolesIds revoking every role.
+func pcuUpdateRoles(d *schema.ResourceData, config *Config) error {
+	projectId := d.Get("project_id").(string)
+
+	projectRoles, err := GetPublicCloudRoles(config, projectId)
+	if err != nil {
+		return err
+	}
+
+	ids, err := publicCloudRoleIds(projectRoles, pcuRoleNames(d))
+	if err != nil {
+		return fmt.Errorf("[ERROR] updating roles of user %s: %s", d.Id(), err)
+	}
+
+	log.Printf("[DEBUG] Will update roles of public cloud user %s from project %s: %v", d.Id(), projectId, ids)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/role", projectId, d.Id())
+	err = config.OVHClient.Put(endpoint, &pcuRolesParams{RolesIds: ids}, nil)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling Put %s:\n\t %q", endpoint, err)
+	}
+
+	return nil
+}
+
 func resourcePublicCloudUserRegeneratePassword(d *schema.ResourceData, meta interface{}) error {
 	config := meta.(*Config)
 
@@ -246,9 +532,9 @@ func resourcePublicCloudUserRegeneratePassword(d *schema.ResourceData, meta inte
 		Pending:    []string{"updating"},
 		Target:     []string{"ok"},
 		Refresh:    pcuRefreshFunc(config.OVHClient, projectId, strconv.Itoa(r.Id)),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutUpdate),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -257,29 +543,57 @@ func resourcePublicCloudUserRegeneratePassword(d *schema.ResourceData, meta inte
 	}
 	log.Printf("[DEBUG] Read User with new password %s", r)
 
-	readPcu(d, r, true)
+	if err := readPcu(d, r, true); err != nil {
+		return err
+	}
 
-	openstackrc := make(map[string]string)
-	err = pcuGetOpenstackRC(projectId, d.Id(), config.OVHClient, openstackrc)
-	if err != nil {
+	if err := pcuSetOpenstackRC(d, config); err != nil {
 		return fmt.Errorf("[ERROR] Reading openstack creds for user %s: %s", d.Id(), err)
 	}
-
-	d.Set("openstack_rc", &openstackrc)
 	d.Partial(false)
 	log.Printf("[DEBUG] Read Public Cloud User %s", r)
 	return nil
 }
 
-func readPcu(d *schema.ResourceData, r *pcuResponse, setPassword bool) {
+func readPcu(d *schema.ResourceData, r *pcuResponse, setPassword bool) error {
 	d.Set("description", r.Description)
 	d.Set("status", r.Status)
 	d.Set("creation_date", r.CreationDate)
 	d.Set("username", r.Username)
-	if setPassword {
-		d.Set("password", r.Password)
+
+	roles := make([]string, 0, len(r.Roles))
+	for _, role := range r.Roles {
+		roles = append(roles, role.Name)
 	}
+	d.Set("roles", roles)
+
 	d.SetId(strconv.Itoa(r.Id))
+	if setPassword {
+		return pcuSetPassword(d, r.Password)
+	}
+	return nil
+}
+
+// pcuSetPassword stores the password in clear, or only its encrypted form
+// and the key fingerprint when a pgp_key is set.
+func pcuSetPassword(d *schema.ResourceData, password string) error {
+	pgpKey := d.Get("pgp_key").(string)
+	if pgpKey == "" {
+		d.Set("password", password)
+		d.Set("encrypted_password", "")
+		d.Set("key_fingerprint", "")
+		return nil
+	}
+
+	fingerprint, encrypted, err := pgpEncryptValue(pgpKey, password)
+	if err != nil {
+		return fmt.Errorf("[ERROR] encrypting password of user %s: %s", d.Id(), err)
+	}
+
+	d.Set("password", "")
+	d.Set("encrypted_password", encrypted)
+	d.Set("key_fingerprint", fingerprint)
+	return nil
 }
 
 func resourcePublicCloudUserDelete(d *schema.ResourceData, meta interface{}) error {
@@ -303,9 +617,9 @@ func resourcePublicCloudUserDelete(d *schema.ResourceData, meta interface{}) err
 		Pending:    []string{"deleting"},
 		Target:     []string{"deleted"},
 		Refresh:    pcuDeleteRefreshFunc(config.OVHClient, projectId, id),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutDelete),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -319,7 +633,7 @@ func resourcePublicCloudUserDelete(d *schema.ResourceData, meta interface{}) err
 	return nil
 }
 
-func pcuExists(projectId, id string, c *ovh.Client) error {
+func pcuExists(projectId, id string, c *OVHClient) error {
 	r := &pcuResponse{}
 
 	log.Printf("[DEBUG] Will read public cloud user for project: %s, id: %s", projectId, id)
@@ -335,7 +649,7 @@ func pcuExists(projectId, id string, c *ovh.Client) error {
 	return nil
 }
 
-func pcuRefreshFunc(c *ovh.Client, projectId, pcuId string) resource.StateRefreshFunc {
+func pcuRefreshFunc(c *OVHClient, projectId, pcuId string) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := &pcuResponse{}
 		endpoint := fmt.Sprintf("/cloud/project/%s/user/%s", projectId, pcuId)
@@ -349,13 +663,13 @@ func pcuRefreshFunc(c *ovh.Client, projectId, pcuId string) resource.StateRefres
 	}
 }
 
-func pcuDeleteRefreshFunc(c *ovh.Client, projectId, pcuId string) resource.StateRefreshFunc {
+func pcuDeleteRefreshFunc(c *OVHClient, projectId, pcuId string) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := &pcuResponse{}
 		endpoint := fmt.Sprintf("/cloud/project/%s/user/%s", projectId, pcuId)
 		err := c.Get(endpoint, r)
 		if err != nil {
-			if err.(*ovh.APIError).Code == 404 {
+			if ovherr.IsNotFound(err) {
 				log.Printf("[DEBUG] user id %s on project %s deleted", pcuId, projectId)
 				return r, "deleted", nil
 			} else {
@@ -0,0 +1,167 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"regexp"
+)
+
+var pcus3cAccessRules = []AccessRule{
+	{"POST", "/cloud/project/*/user/*/s3Credentials"},
+	{"GET", "/cloud/project/*/user/*/s3Credentials/*"},
+	{"DELETE", "/cloud/project/*/user/*/s3Credentials/*"},
+	{"POST", "/cloud/project/*/user/*/s3Credentials/*/secret"},
+}
+
+func resourcePublicCloudUserS3Credential() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePublicCloudUserS3CredentialCreate,
+		Read:   resourcePublicCloudUserS3CredentialRead,
+		Delete: resourcePublicCloudUserS3CredentialDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourcePublicCloudUserS3CredentialImportState,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OVH_PROJECT_ID", ""),
+			},
+			"user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"access_key_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"secret_access_key": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"openstack_user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// pcus3cImportID matches import ids of the form project_id/user_id/access_key_id
+var pcus3cImportID = regexp.MustCompile("^([^/]+)/([^/]+)/([^/]+)$")
+
+// resourcePublicCloudUserS3CredentialImportState imports s3 credentials from
+// project_id/user_id/access_key_id ids. The secret key isn't returned along
+// with the credential, so it's read from its own endpoint.
+func resourcePublicCloudUserS3CredentialImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	params := pcus3cImportID.FindStringSubmatch(d.Id())
+	if params == nil {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id, user id nor access key id from id %q, expected project_id/user_id/access_key_id", d.Id())
+	}
+
+	d.Set("project_id", params[1])
+	d.Set("user_id", params[2])
+	d.SetId(params[3])
+
+	config := meta.(*Config)
+	secret := &pcus3cSecretResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/s3Credentials/%s/secret", params[1], params[2], params[3])
+
+	err := config.OVHClient.PostIdempotent(endpoint, nil, secret)
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] calling Post %s:\n\t %q", endpoint, err)
+	}
+
+	d.Set("secret_access_key", secret.Secret)
+	return []*schema.ResourceData{d}, nil
+}
+
+type pcus3cResponse struct {
+	Access   string `json:"access"`
+	Secret   string `json:"secret"`
+	UserId   string `json:"userId"`
+	TenantId string `json:"tenantId"`
+}
+
+func (p *pcus3cResponse) String() string {
+	return fmt.Sprintf("S3Credential[Access: %s, UserId: %s, TenantId: %s]", p.Access, p.UserId, p.TenantId)
+}
+
+type pcus3cSecretResponse struct {
+	Secret string `json:"secret"`
+}
+
+func resourcePublicCloudUserS3CredentialCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+
+	r := &pcus3cResponse{}
+
+	log.Printf("[DEBUG] Will create s3 credential for public cloud user %s from project: %s", userId, projectId)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/s3Credentials", projectId, userId)
+
+	err := config.OVHClient.Post(endpoint, nil, r)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling Post %s:\n\t %q", endpoint, err)
+	}
+
+	log.Printf("[DEBUG] Created S3 Credential %s", r)
+
+	d.SetId(r.Access)
+	d.Set("secret_access_key", r.Secret)
+
+	return resourcePublicCloudUserS3CredentialRead(d, meta)
+}
+
+func resourcePublicCloudUserS3CredentialRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+
+	r := &pcus3cResponse{}
+
+	log.Printf("[DEBUG] Will read s3 credential %s of public cloud user %s from project: %s", d.Id(), userId, projectId)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/s3Credentials/%s", projectId, userId, d.Id())
+
+	err := config.OVHClient.Get(endpoint, r)
+	if err != nil {
+		return CheckDeleted(d, err, endpoint)
+	}
+
+	d.Set("access_key_id", r.Access)
+	d.Set("openstack_user_id", r.UserId)
+
+	log.Printf("[DEBUG] Read S3 Credential %s", r)
+	return nil
+}
+
+func resourcePublicCloudUserS3CredentialDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+
+	log.Printf("[DEBUG] Will delete s3 credential %s of public cloud user %s from project: %s", d.Id(), userId, projectId)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/s3Credentials/%s", projectId, userId, d.Id())
+
+	err := config.OVHClient.Delete(endpoint, nil)
+	if err != nil {
+		return CheckDeleted(d, err, endpoint)
+	}
+
+	log.Printf("[DEBUG] Deleted S3 Credential %s of public cloud user %s", d.Id(), userId)
+
+	d.SetId("")
+	return nil
+}
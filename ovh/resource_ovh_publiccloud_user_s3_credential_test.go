@@ -0,0 +1,108 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"os"
+	"strconv"
+	"testing"
+)
+
+const testAccPublicCloudUserS3CredentialConfigTemplate = `
+resource "ovh_publiccloud_user" "user" {
+  project_id  = "%s"
+  description = "my s3 user for acceptance tests"
+  roles       = ["objectstore_operator"]
+}
+
+resource "ovh_publiccloud_user_s3_credential" "credential" {
+  project_id = "${ovh_publiccloud_user.user.project_id}"
+  user_id    = "${ovh_publiccloud_user.user.id}"
+}
+`
+
+func testAccPublicCloudUserS3CredentialConfig() string {
+	return fmt.Sprintf(testAccPublicCloudUserS3CredentialConfigTemplate, os.Getenv("OVH_PUBLIC_CLOUD"))
+}
+
+func TestAccPublicCloudUserS3Credential_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccCheckPublicCloudUserPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPublicCloudUserS3CredentialDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublicCloudUserS3CredentialConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("ovh_publiccloud_user_s3_credential.credential", "access_key_id"),
+					resource.TestCheckResourceAttrSet("ovh_publiccloud_user_s3_credential.credential", "secret_access_key"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckPublicCloudUserS3CredentialDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Config)
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "ovh_publiccloud_user_s3_credential" {
+			continue
+		}
+
+		endpoint := fmt.Sprintf(
+			"/cloud/project/%s/user/%s/s3Credentials/%s",
+			rs.Primary.Attributes["project_id"],
+			rs.Primary.Attributes["user_id"],
+			rs.Primary.ID,
+		)
+		if err := config.OVHClient.Get(endpoint, &pcus3cResponse{}); err == nil {
+			return fmt.Errorf("Public Cloud User S3 Credential still exists")
+		}
+	}
+	return nil
+}
+
+func TestPublicCloudUserS3Credential(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	r := resourcePublicCloudUserS3Credential()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"user_id":    strconv.Itoa(user.Id),
+	})
+
+	if err := resourcePublicCloudUserS3CredentialCreate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	access, secret := d.Get("access_key_id").(string), d.Get("secret_access_key").(string)
+	if access == "" || access != d.Id() || secret == "" {
+		t.Fatalf("expected an access key id and a secret, got %q and %q", access, secret)
+	}
+
+	imported := r.Data(nil)
+	imported.SetId(fmt.Sprintf("%s/%d/%s", ovhtest.ProjectId, user.Id, access))
+	if _, err := r.Importer.State(imported, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if imported.Id() != access || imported.Get("secret_access_key").(string) != secret {
+		t.Errorf("expected credential %s to be imported with its secret, got %s", access, imported.Id())
+	}
+
+	if err := resourcePublicCloudUserS3CredentialDelete(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	d.SetId(access)
+	if err := resourcePublicCloudUserS3CredentialRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != "" {
+		t.Errorf("credential should have been removed from state, got id %s", d.Id())
+	}
+}
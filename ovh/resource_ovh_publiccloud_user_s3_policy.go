@@ -0,0 +1,145 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"regexp"
+)
+
+var pcus3pAccessRules = []AccessRule{
+	{"GET", "/cloud/project/*/user/*/policy"},
+	{"POST", "/cloud/project/*/user/*/policy"},
+}
+
+func resourcePublicCloudUserS3Policy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePublicCloudUserS3PolicyCreateOrUpdate,
+		Read:   resourcePublicCloudUserS3PolicyRead,
+		Update: resourcePublicCloudUserS3PolicyCreateOrUpdate,
+		Delete: resourcePublicCloudUserS3PolicyDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourcePublicCloudUserS3PolicyImportState,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"project_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OVH_PROJECT_ID", ""),
+			},
+			"user_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"policy": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateJSON,
+				StateFunc:    normalizeJSONState,
+			},
+		},
+	}
+}
+
+// pcus3pEmptyPolicy is the policy left on users when the resource is
+// destroyed, as the API has no way to remove a policy.
+const pcus3pEmptyPolicy = `{"Statement":[]}`
+
+// pcus3pImportID matches import ids of the form project_id/user_id
+var pcus3pImportID = regexp.MustCompile("^([^/]+)/([^/]+)$")
+
+// resourcePublicCloudUserS3PolicyImportState imports s3 policies from
+// project_id/user_id ids.
+func resourcePublicCloudUserS3PolicyImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	params := pcus3pImportID.FindStringSubmatch(d.Id())
+	if params == nil {
+		return nil, fmt.Errorf("[ERROR] couldn't extract project id nor user id from id %q, expected project_id/user_id", d.Id())
+	}
+
+	d.Set("project_id", params[1])
+	d.Set("user_id", params[2])
+	d.SetId(params[2])
+	return []*schema.ResourceData{d}, nil
+}
+
+type pcus3pParams struct {
+	Policy string `json:"policy"`
+}
+
+func (p *pcus3pParams) String() string {
+	return fmt.Sprintf("S3PolicyParams[policy: %s]", p.Policy)
+}
+
+func resourcePublicCloudUserS3PolicyCreateOrUpdate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+	params := &pcus3pParams{Policy: d.Get("policy").(string)}
+
+	log.Printf("[DEBUG] Will set s3 policy of public cloud user %s from project %s: %s", userId, projectId, params)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/policy", projectId, userId)
+
+	err := config.OVHClient.PostIdempotent(endpoint, params, nil)
+	if err != nil {
+		return fmt.Errorf("[ERROR] calling Post %s with params %s:\n\t %q", endpoint, params, err)
+	}
+
+	d.SetId(userId)
+
+	return resourcePublicCloudUserS3PolicyRead(d, meta)
+}
+
+func resourcePublicCloudUserS3PolicyRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+
+	r := &pcus3pParams{}
+
+	log.Printf("[DEBUG] Will read s3 policy of public cloud user %s from project: %s", userId, projectId)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/policy", projectId, userId)
+
+	err := config.OVHClient.Get(endpoint, r)
+	if err != nil {
+		return CheckDeleted(d, err, endpoint)
+	}
+
+	if r.Policy == "" {
+		d.Set("policy", "")
+	} else {
+		policy, err := normalizeJSON(r.Policy)
+		if err != nil {
+			return fmt.Errorf("[ERROR] reading s3 policy of user %s: %s", userId, err)
+		}
+		d.Set("policy", policy)
+	}
+
+	log.Printf("[DEBUG] Read S3 Policy of public cloud user %s", userId)
+	return nil
+}
+
+func resourcePublicCloudUserS3PolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	projectId := d.Get("project_id").(string)
+	userId := d.Get("user_id").(string)
+
+	log.Printf("[DEBUG] Will remove s3 policy of public cloud user %s from project: %s", userId, projectId)
+
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%s/policy", projectId, userId)
+
+	err := config.OVHClient.PostIdempotent(endpoint, &pcus3pParams{Policy: pcus3pEmptyPolicy}, nil)
+	if err != nil {
+		return CheckDeleted(d, err, endpoint)
+	}
+
+	d.SetId("")
+	return nil
+}
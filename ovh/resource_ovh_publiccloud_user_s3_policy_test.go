@@ -0,0 +1,109 @@
+package ovh
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"os"
+	"strconv"
+	"testing"
+)
+
+const testAccPublicCloudUserS3PolicyConfigTemplate = `
+resource "ovh_publiccloud_user" "user" {
+  project_id  = "%s"
+  description = "my s3 user for acceptance tests"
+  roles       = ["objectstore_operator"]
+}
+
+resource "ovh_publiccloud_user_s3_policy" "policy" {
+  project_id = "${ovh_publiccloud_user.user.project_id}"
+  user_id    = "${ovh_publiccloud_user.user.id}"
+  policy     = <<EOF
+{
+  "Statement": [
+    {
+      "Sid": "ReadOnly",
+      "Effect": "Allow",
+      "Action": ["s3:GetObject", "s3:ListBucket"],
+      "Resource": ["arn:aws:s3:::my-bucket", "arn:aws:s3:::my-bucket/*"]
+    }
+  ]
+}
+EOF
+}
+`
+
+func testAccPublicCloudUserS3PolicyConfig() string {
+	return fmt.Sprintf(testAccPublicCloudUserS3PolicyConfigTemplate, os.Getenv("OVH_PUBLIC_CLOUD"))
+}
+
+func TestAccPublicCloudUserS3Policy_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccCheckPublicCloudUserPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccPublicCloudUserS3PolicyConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("ovh_publiccloud_user_s3_policy.policy", "policy"),
+				),
+			},
+		},
+	})
+}
+
+func TestPublicCloudUserS3Policy(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	r := resourcePublicCloudUserS3Policy()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"user_id":    strconv.Itoa(user.Id),
+		"policy":     `{ "Statement": [ { "Effect": "Allow", "Action": ["s3:*"] } ] }`,
+	})
+
+	if err := resourcePublicCloudUserS3PolicyCreateOrUpdate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"Statement":[{"Action":["s3:*"],"Effect":"Allow"}]}`
+	if policy := d.Get("policy").(string); policy != expected {
+		t.Errorf("expected policy %s, got %s", expected, policy)
+	}
+
+	imported := r.Data(nil)
+	imported.SetId(fmt.Sprintf("%s/%d", ovhtest.ProjectId, user.Id))
+	if _, err := r.Importer.State(imported, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := resourcePublicCloudUserS3PolicyRead(imported, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if policy := imported.Get("policy").(string); policy != expected {
+		t.Errorf("expected imported policy %s, got %s", expected, policy)
+	}
+
+	if err := resourcePublicCloudUserS3PolicyDelete(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := resourcePublicCloudUserS3PolicyRead(imported, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if policy := imported.Get("policy").(string); policy != pcus3pEmptyPolicy {
+		t.Errorf("expected the policy to be emptied, got %s", policy)
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	if _, errs := validateJSON(`{"Statement": []}`, "policy"); len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if _, errs := validateJSON(`{"Statement": [}`, "policy"); len(errs) == 0 {
+		t.Errorf("expected an error for an invalid document")
+	}
+}
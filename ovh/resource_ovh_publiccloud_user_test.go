@@ -3,17 +3,26 @@ package ovh
 import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
-var testAccPublicCloudUserConfig = fmt.Sprintf(`
+const testAccPublicCloudUserConfigTemplate = `
 resource "ovh_publiccloud_user" "user" {
 	project_id  = "%s"
   description = "my user for acceptance tests"
 }
-`, os.Getenv("OVH_PUBLIC_CLOUD"))
+`
+
+func testAccPublicCloudUserConfig() string {
+	return fmt.Sprintf(testAccPublicCloudUserConfigTemplate, os.Getenv("OVH_PUBLIC_CLOUD"))
+}
 
 func TestAccPublicCloudUser_basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
@@ -22,7 +31,7 @@ func TestAccPublicCloudUser_basic(t *testing.T) {
 		CheckDestroy: testAccCheckPublicCloudUserDestroy,
 		Steps: []resource.TestStep{
 			resource.TestStep{
-				Config: testAccPublicCloudUserConfig,
+				Config: testAccPublicCloudUserConfig(),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckPublicCloudUserExists("ovh_publiccloud_user.user", t),
 					testAccCheckPublicCloudUserOpenRC("ovh_publiccloud_user.user", t),
@@ -104,3 +113,411 @@ func testAccCheckPublicCloudUserDestroy(s *terraform.State) error {
 	}
 	return nil
 }
+
+func TestPublicCloudUserRead_notFound(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudUser().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+	})
+	d.SetId("42")
+
+	if err := resourcePublicCloudUserRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("user should have been removed from state, got id %s", d.Id())
+	}
+}
+
+func testFakePublicCloudUser(t *testing.T, config *Config) *pcuResponse {
+	user := &pcuResponse{}
+	endpoint := fmt.Sprintf("/cloud/project/%s/user", ovhtest.ProjectId)
+	if err := config.OVHClient.Post(endpoint, &pcuCreateParams{Description: "imported"}, user); err != nil {
+		t.Fatalf("couldn't create user: %s", err)
+	}
+	if err := config.OVHClient.Get(fmt.Sprintf("%s/%d", endpoint, user.Id), &pcuResponse{}); err != nil {
+		t.Fatalf("couldn't read user: %s", err)
+	}
+	return user
+}
+
+func TestPublicCloudUserImportState(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	r := resourcePublicCloudUser()
+
+	d := r.Data(nil)
+	d.SetId(fmt.Sprintf("%s/%d", ovhtest.ProjectId, user.Id))
+	if _, err := r.Importer.State(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d.Id() != strconv.Itoa(user.Id) || d.Get("project_id").(string) != ovhtest.ProjectId {
+		t.Errorf("expected user %d of project %s, got %s of %s", user.Id, ovhtest.ProjectId, d.Id(), d.Get("project_id"))
+	}
+	if d.Get("password").(string) != "" {
+		t.Errorf("password shouldn't be regenerated on import by default")
+	}
+
+	config.RegeneratePasswordOnImport = true
+	d = r.Data(nil)
+	d.SetId(fmt.Sprintf("%s/%d", ovhtest.ProjectId, user.Id))
+	if _, err := r.Importer.State(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if password := d.Get("password").(string); password == "" || password == user.Password {
+		t.Errorf("expected a new password when regenerate_password_on_import is set, got %q", password)
+	}
+
+	d = r.Data(nil)
+	d.SetId(ovhtest.ProjectId + "/not-a-user-id")
+	if _, err := r.Importer.State(d, config); err == nil {
+		t.Errorf("expected an error for a malformed id")
+	}
+}
+
+func TestPublicCloudUserUpdate_rotatePassword(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudUser().Schema, map[string]interface{}{
+		"project_id":                ovhtest.ProjectId,
+		"password_rotation_keepers": map[string]interface{}{"rotated_at": "2018-01-01"},
+	})
+	d.SetId(strconv.Itoa(user.Id))
+	d.Set("password", user.Password)
+
+	if err := resourcePublicCloudUserUpdate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != strconv.Itoa(user.Id) {
+		t.Errorf("expected user %d to be kept, got %s", user.Id, d.Id())
+	}
+	if password := d.Get("password").(string); password == "" || password == user.Password {
+		t.Errorf("expected a new password, got %q", password)
+	}
+}
+
+// testPcuPgpKeyUpdate applies the change of the pgp_key of user from
+// oldKey, its state holding password, to newKey.
+func testPcuPgpKeyUpdate(t *testing.T, config *Config, user *pcuResponse, password, oldKey, newKey string) *terraform.InstanceState {
+	r := resourcePublicCloudUser()
+
+	d := r.Data(nil)
+	d.SetId(strconv.Itoa(user.Id))
+	d.Set("project_id", ovhtest.ProjectId)
+	d.Set("password", password)
+	d.Set("pgp_key", oldKey)
+
+	diff, err := r.Diff(d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"pgp_key":    newKey,
+	}), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	state, err := r.Apply(d.State(), diff, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state.ID != strconv.Itoa(user.Id) {
+		t.Errorf("expected user %d to be kept, got %s", user.Id, state.ID)
+	}
+	if state.Attributes["password"] != "" {
+		t.Errorf("password shouldn't be stored in clear with a pgp_key, got %q", state.Attributes["password"])
+	}
+	return state
+}
+
+func TestPublicCloudUserUpdate_pgpKey(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	_, oldKey, _ := testPGPEntity(t)
+	entity, key, _ := testPGPEntity(t)
+
+	state := testPcuPgpKeyUpdate(t, config, user, "", oldKey, key)
+
+	if fingerprint := state.Attributes["key_fingerprint"]; fingerprint != pgpKeyFingerprint(entity) {
+		t.Errorf("expected key_fingerprint %s, got %s", pgpKeyFingerprint(entity), fingerprint)
+	}
+	if password := testPGPDecrypt(t, entity, state.Attributes["encrypted_password"]); password == "" || password == user.Password {
+		t.Errorf("expected a new encrypted password, got %q", password)
+	}
+}
+
+func TestPublicCloudUserUpdate_firstPgpKey(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	entity, key, _ := testPGPEntity(t)
+
+	state := testPcuPgpKeyUpdate(t, config, user, user.Password, "", key)
+
+	if fingerprint := state.Attributes["key_fingerprint"]; fingerprint != pgpKeyFingerprint(entity) {
+		t.Errorf("expected key_fingerprint %s, got %s", pgpKeyFingerprint(entity), fingerprint)
+	}
+	if password := testPGPDecrypt(t, entity, state.Attributes["encrypted_password"]); password != user.Password {
+		t.Errorf("expected the current password %q to be encrypted, got %q", user.Password, password)
+	}
+
+	// Without the password in state, there's nothing to encrypt.
+	state = testPcuPgpKeyUpdate(t, config, user, "", "", key)
+	if password := testPGPDecrypt(t, entity, state.Attributes["encrypted_password"]); password == "" || password == user.Password {
+		t.Errorf("expected a new encrypted password for a user imported without one, got %q", password)
+	}
+}
+
+func TestPublicCloudUserUpdate_revokeRoles(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+	endpoint := fmt.Sprintf("/cloud/project/%s/user/%d", ovhtest.ProjectId, user.Id)
+
+	r := resourcePublicCloudUser()
+	d := r.Data(nil)
+	d.SetId(strconv.Itoa(user.Id))
+	d.Set("project_id", ovhtest.ProjectId)
+	d.Set("roles", []string{"network_operator"})
+	if err := pcuUpdateRoles(d, config); err != nil {
+		t.Fatalf("couldn't set the roles of user %d: %s", user.Id, err)
+	}
+
+	state := d.State()
+
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+	}), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff != nil && diff.Attributes["roles.#"] != nil {
+		t.Errorf("expected no roles diff without roles, got %v", diff.Attributes["roles.#"])
+	}
+
+	diff, err = r.Diff(state, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"roles":      []interface{}{},
+	}), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff == nil || diff.Attributes["roles.#"] == nil {
+		t.Fatalf("expected a roles diff with empty roles")
+	}
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if state.Attributes["roles.#"] != "0" {
+		t.Errorf("expected no roles in state, got %s", state.Attributes["roles.#"])
+	}
+	r2 := &pcuResponse{}
+	if err := config.OVHClient.Get(endpoint, r2); err != nil {
+		t.Fatalf("couldn't read user %d: %s", user.Id, err)
+	}
+	if len(r2.Roles) != 0 {
+		t.Errorf("expected every role to be revoked, got %v", r2.Roles)
+	}
+}
+
+func TestPublicCloudUserDiff_passwordRotation(t *testing.T) {
+	r := resourcePublicCloudUser()
+	_, key, _ := testPGPEntity(t)
+	state := &terraform.InstanceState{
+		ID: "1000",
+		Attributes: map[string]string{
+			"id":                           "1000",
+			"project_id":                   ovhtest.ProjectId,
+			"password":                     "secret",
+			"openrc_content":               "#!/bin/bash\n",
+			"clouds_yaml":                  "clouds:\n",
+			"password_rotation_keepers.%":  "1",
+			"password_rotation_keepers.at": "2018-01-01",
+		},
+	}
+
+	cases := []struct {
+		name   string
+		config map[string]interface{}
+		rotate bool
+	}{
+		{"unchanged", map[string]interface{}{"password_rotation_keepers": map[string]interface{}{"at": "2018-01-01"}}, false},
+		{"keepers", map[string]interface{}{"password_rotation_keepers": map[string]interface{}{"at": "2019-01-01"}}, true},
+		{"pgp_key", map[string]interface{}{"password_rotation_keepers": map[string]interface{}{"at": "2018-01-01"}, "pgp_key": key}, true},
+	}
+
+	for _, c := range cases {
+		c.config["project_id"] = ovhtest.ProjectId
+		diff, err := r.Diff(state, terraform.NewResourceConfigRaw(c.config), nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+			continue
+		}
+
+		for _, k := range pcuPasswordAttributes {
+			var computed bool
+			if diff != nil && diff.Attributes[k] != nil {
+				computed = diff.Attributes[k].NewComputed
+			}
+			if computed != c.rotate {
+				t.Errorf("%s: expected %s to be computed: %v, got %v", c.name, k, c.rotate, computed)
+			}
+		}
+	}
+}
+
+func TestPcuParseOpenrc(t *testing.T) {
+	content := `#!/bin/bash
+export OS_AUTH_URL=https://auth.cloud.ovh.net/v3/
+export OS_IDENTITY_API_VERSION=3
+export OS_USER_DOMAIN_NAME=${OS_USER_DOMAIN_NAME:-"Default"}
+export OS_PROJECT_DOMAIN_NAME=${OS_PROJECT_DOMAIN_NAME:-"Default"}
+export OS_TENANT_ID=0123456789abcdef
+export OS_TENANT_NAME="1234567890"
+export OS_USERNAME="user-abc"
+echo "Please enter your OpenStack Password: "
+read -sr OS_PASSWORD_INPUT
+export OS_PASSWORD=$OS_PASSWORD_INPUT
+export OS_REGION_NAME="GRA1"
+if [ -z "$OS_REGION_NAME" ]; then unset OS_REGION_NAME; fi
+`
+
+	rc, err := pcuParseOpenrc(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"OS_AUTH_URL":             "https://auth.cloud.ovh.net/v3/",
+		"OS_IDENTITY_API_VERSION": "3",
+		"OS_USER_DOMAIN_NAME":     "Default",
+		"OS_PROJECT_DOMAIN_NAME":  "Default",
+		"OS_TENANT_ID":            "0123456789abcdef",
+		"OS_TENANT_NAME":          "1234567890",
+		"OS_PROJECT_ID":           "0123456789abcdef",
+		"OS_PROJECT_NAME":         "1234567890",
+		"OS_USERNAME":             "user-abc",
+		"OS_REGION_NAME":          "GRA1",
+	}
+	if !reflect.DeepEqual(rc, expected) {
+		t.Errorf("expected %v, got %v", expected, rc)
+	}
+
+	if _, err := pcuParseOpenrc("export OS_AUTH_URL=https://auth.cloud.ovh.net/v3/\n"); err == nil {
+		t.Errorf("expected an error for an incomplete openrc")
+	}
+}
+
+func TestPublicCloudUserRead_openrc(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudUser().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"region":     "GRA1",
+	})
+	d.SetId(strconv.Itoa(user.Id))
+	d.Set("password", "secret")
+
+	if err := resourcePublicCloudUserRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for name, expected := range map[string]string{
+		"openstack_rc.OS_PROJECT_ID":           ovhtest.ProjectId,
+		"openstack_rc.OS_USER_DOMAIN_NAME":     "Default",
+		"openstack_rc.OS_PROJECT_DOMAIN_NAME":  "Default",
+		"openstack_rc.OS_IDENTITY_API_VERSION": "3",
+		"openstack_rc.OS_REGION_NAME":          "GRA1",
+	} {
+		if value := d.Get(name).(string); value != expected {
+			t.Errorf("expected %s to be %q, got %q", name, expected, value)
+		}
+	}
+
+	openrc := d.Get("openrc_content").(string)
+	for _, line := range []string{
+		`export OS_AUTH_URL="https://auth.cloud.ovh.net/v3/"`,
+		`export OS_PROJECT_ID="` + ovhtest.ProjectId + `"`,
+		`export OS_PASSWORD="secret"`,
+		`export OS_REGION_NAME="GRA1"`,
+	} {
+		if !strings.Contains(openrc, line+"\n") {
+			t.Errorf("expected openrc_content to contain %s, got:\n%s", line, openrc)
+		}
+	}
+
+	clouds := d.Get("clouds_yaml").(string)
+	for _, line := range []string{
+		`      auth_url: "https://auth.cloud.ovh.net/v3/"`,
+		`      password: "secret"`,
+		`      user_domain_name: "Default"`,
+		`    region_name: "GRA1"`,
+		`    identity_api_version: 3`,
+	} {
+		if !strings.Contains(clouds, line+"\n") {
+			t.Errorf("expected clouds_yaml to contain %s, got:\n%s", line, clouds)
+		}
+	}
+
+	d.Set("region", "")
+	d.Set("password", "")
+	if err := resourcePublicCloudUserRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if region := d.Get("openstack_rc.OS_REGION_NAME").(string); region != "" {
+		t.Errorf("expected no region without a region argument, got %q", region)
+	}
+	if strings.Contains(d.Get("openrc_content").(string), "OS_PASSWORD") {
+		t.Errorf("openrc_content shouldn't set OS_PASSWORD when the password is unknown")
+	}
+}
+
+func TestPublicCloudUserUpdate_roles(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	user := testFakePublicCloudUser(t, config)
+
+	d := schema.TestResourceDataRaw(t, resourcePublicCloudUser().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"roles":      []interface{}{"objectstore_operator", "network_operator"},
+	})
+	d.SetId(strconv.Itoa(user.Id))
+
+	if err := resourcePublicCloudUserUpdate(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"network_operator", "objectstore_operator"}
+	if roles := pcuRoleNames(d); !reflect.DeepEqual(roles, expected) {
+		t.Errorf("expected roles %v, got %v", expected, roles)
+	}
+	if d.Id() != strconv.Itoa(user.Id) {
+		t.Errorf("expected user %d to be kept, got %s", user.Id, d.Id())
+	}
+
+	d = schema.TestResourceDataRaw(t, resourcePublicCloudUser().Schema, map[string]interface{}{
+		"project_id": ovhtest.ProjectId,
+		"roles":      []interface{}{"unknown_operator"},
+	})
+	d.SetId(strconv.Itoa(user.Id))
+
+	if err := resourcePublicCloudUserUpdate(d, config); err == nil {
+		t.Errorf("expected an error for an unknown role")
+	}
+}
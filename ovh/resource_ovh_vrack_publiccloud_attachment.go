@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/ovh/go-ovh/ovh"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
 	"log"
 	"regexp"
 	"time"
@@ -12,6 +12,13 @@ import (
 
 var vpcaID = regexp.MustCompile("vrack_(.+)-cloudproject_(.+)-attach")
 
+var vrackPublicCloudAttachmentAccessRules = []AccessRule{
+	{"POST", "/vrack/*/cloudProject"},
+	{"GET", "/vrack/*/cloudProject/*"},
+	{"DELETE", "/vrack/*/cloudProject/*"},
+	{"GET", "/vrack/*/task/*"},
+}
+
 func resourceVRackPublicCloudAttachment() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVRackPublicCloudAttachmentCreate,
@@ -31,6 +38,11 @@ func resourceVRackPublicCloudAttachment() *schema.Resource {
 			},
 		},
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(unsetTimeout),
+			Delete: schema.DefaultTimeout(unsetTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"vrack_id": &schema.Schema{
 				Type:        schema.TypeString,
@@ -91,9 +103,9 @@ func resourceVRackPublicCloudAttachmentCreate(d *schema.ResourceData, meta inter
 		Pending:    []string{"init", "todo", "doing"},
 		Target:     []string{"completed"},
 		Refresh:    VRackTaskRefreshFunc(config.OVHClient, vrackId, r.Id),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutCreate),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -118,7 +130,7 @@ func resourceVRackPublicCloudAttachmentRead(d *schema.ResourceData, meta interfa
 
 	err := config.OVHClient.Get(endpoint, &r)
 	if err != nil {
-		return err
+		return CheckDeleted(d, err, endpoint)
 	}
 	log.Printf("[DEBUG] Read VRack %s ->  PublicCloud %s", vrackId, params.Project)
 
@@ -145,9 +157,9 @@ func resourceVRackPublicCloudAttachmentDelete(d *schema.ResourceData, meta inter
 		Pending:    []string{"init", "todo", "doing"},
 		Target:     []string{"completed"},
 		Refresh:    VRackTaskRefreshFunc(config.OVHClient, vrackId, r.Id),
-		Timeout:    10 * time.Minute,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+		Timeout:    operationTimeout(d, meta, schema.TimeoutDelete),
+		Delay:      operationPollDelay,
+		MinTimeout: operationPollMinTimeout,
 	}
 
 	_, err = stateConf.WaitForState()
@@ -160,7 +172,7 @@ func resourceVRackPublicCloudAttachmentDelete(d *schema.ResourceData, meta inter
 	return nil
 }
 
-func vrackPublicCloudAttachmentExists(vrackId, projectId string, c *ovh.Client) error {
+func vrackPublicCloudAttachmentExists(vrackId, projectId string, c *OVHClient) error {
 	type attachResponse struct {
 		VRack   string `json:"vrack"`
 		Project string `json:"project"`
@@ -181,13 +193,13 @@ func vrackPublicCloudAttachmentExists(vrackId, projectId string, c *ovh.Client)
 
 // AttachmentStateRefreshFunc returns a resource.StateRefreshFunc that is used to watch
 // an Attachment Task.
-func VRackTaskRefreshFunc(c *ovh.Client, serviceName string, taskId int) resource.StateRefreshFunc {
+func VRackTaskRefreshFunc(c *OVHClient, serviceName string, taskId int) resource.StateRefreshFunc {
 	return func() (interface{}, string, error) {
 		r := attachTaskResponse{}
 		endpoint := fmt.Sprintf("/vrack/%s/task/%d", serviceName, taskId)
 		err := c.Get(endpoint, &r)
 		if err != nil {
-			if err.(*ovh.APIError).Code == 404 {
+			if ovherr.IsNotFound(err) {
 				log.Printf("[DEBUG] Task id %d on VRack %s completed", taskId, serviceName)
 				return taskId, "completed", nil
 			} else {
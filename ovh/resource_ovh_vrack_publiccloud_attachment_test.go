@@ -3,17 +3,23 @@ package ovh
 import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
 	"os"
 	"testing"
 )
 
-var testAccVRackPublicCloudAttachmentConfig = fmt.Sprintf(`
+const testAccVRackPublicCloudAttachmentConfigTemplate = `
 resource "ovh_vrack_publiccloud_attachment" "attach" {
   vrack_id = "%s"
 	project_id = "%s"
 }
-`, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+`
+
+func testAccVRackPublicCloudAttachmentConfig() string {
+	return fmt.Sprintf(testAccVRackPublicCloudAttachmentConfigTemplate, os.Getenv("OVH_VRACK"), os.Getenv("OVH_PUBLIC_CLOUD"))
+}
 
 func TestAccVRackPublicCloudAttachment_basic(t *testing.T) {
 	resource.Test(t, resource.TestCase{
@@ -22,7 +28,7 @@ func TestAccVRackPublicCloudAttachment_basic(t *testing.T) {
 		CheckDestroy: testAccCheckVRackPublicCloudAttachmentDestroy,
 		Steps: []resource.TestStep{
 			resource.TestStep{
-				Config: testAccVRackPublicCloudAttachmentConfig,
+				Config: testAccVRackPublicCloudAttachmentConfig(),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckVRackPublicCloudAttachmentExists("ovh_vrack_publiccloud_attachment.attach", t),
 				),
@@ -73,3 +79,22 @@ func testAccCheckVRackPublicCloudAttachmentDestroy(s *terraform.State) error {
 	}
 	return nil
 }
+
+func TestVRackPublicCloudAttachmentRead_notFound(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	defer srv.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceVRackPublicCloudAttachment().Schema, map[string]interface{}{
+		"vrack_id":   ovhtest.VRackId,
+		"project_id": ovhtest.ProjectId,
+	})
+	d.SetId(fmt.Sprintf("vrack_%s-cloudproject_%s-attach", ovhtest.VRackId, ovhtest.ProjectId))
+
+	if err := resourceVRackPublicCloudAttachmentRead(d, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("attachment should have been removed from state, got id %s", d.Id())
+	}
+}
@@ -1,10 +1,97 @@
 package ovh
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovherr"
+	"log"
+	"time"
 )
 
+// defaultOperationTimeout is the timeout of asynchronous operations when
+// neither the resource timeouts block nor the provider default_timeout is set.
+const defaultOperationTimeout = 10 * time.Minute
+
+// operationPollDelay is how long to wait before polling an asynchronous
+// operation, and operationPollMinTimeout the minimum wait between polls.
+// Unit tests against the fake API, which completes operations right away,
+// set both to 0.
+var (
+	operationPollDelay      = 10 * time.Second
+	operationPollMinTimeout = 3 * time.Second
+)
+
+// unsetTimeout is the default of the timeouts block keys, telling
+// operationTimeout the key isn't configured. helper/schema refuses keys
+// declared without a default.
+const unsetTimeout = time.Duration(0)
+
+// operationTimeout returns the timeout of the key operation set in the
+// resource timeouts block, falling back to the provider default_timeout then
+// to defaultOperationTimeout.
+func operationTimeout(d *schema.ResourceData, meta interface{}, key string) time.Duration {
+	if timeout := d.Timeout(key); timeout != unsetTimeout {
+		return timeout
+	}
+	if config, ok := meta.(*Config); ok && config.DefaultTimeout != 0 {
+		return config.DefaultTimeout
+	}
+	return defaultOperationTimeout
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as \"30m\": %s", k, err))
+	}
+	return
+}
+
+func validateJSON(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := normalizeJSON(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a JSON document: %s", k, err))
+	}
+	return
+}
+
+// normalizeJSON re-encodes a JSON document with sorted keys and without
+// spaces, so equivalent documents compare equal.
+func normalizeJSON(s string) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// normalizeJSONState is a StateFunc storing JSON documents normalized,
+// invalid ones being left as is for validation to report them.
+func normalizeJSONState(v interface{}) string {
+	s, err := normalizeJSON(v.(string))
+	if err != nil {
+		return v.(string)
+	}
+	return s
+}
+
+// CheckDeleted checks if the error returned by calling endpoint is a 404
+// and, if so, removes the resource from the state so that Terraform plans
+// a re-create instead of failing.
+func CheckDeleted(d *schema.ResourceData, err error, endpoint string) error {
+	if ovherr.IsNotFound(err) {
+		log.Printf("[WARN] %s not found, removing %s from state", endpoint, d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	return fmt.Errorf("[ERROR] calling %s:\n\t %q", endpoint, err)
+}
+
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -40,4 +127,4 @@ func GetPublicCloudInstance(d *schema.ResourceData, Config *Config) (PublicCloud
 	}
 
 	return response, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,59 @@
+package ovh
+
+import (
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kuachi/terraform-provider-ovh/ovh/ovhtest"
+	"testing"
+	"time"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	r := resourcePublicCloudPrivateNetwork()
+	d := r.Data(nil)
+
+	if timeout := operationTimeout(d, &Config{}, schema.TimeoutCreate); timeout != defaultOperationTimeout {
+		t.Errorf("expected resource default %s, got %s", defaultOperationTimeout, timeout)
+	}
+
+	config := &Config{DefaultTimeout: 45 * time.Minute}
+	if timeout := operationTimeout(d, config, schema.TimeoutCreate); timeout != 45*time.Minute {
+		t.Errorf("expected provider default 45m, got %s", timeout)
+	}
+
+	timeouts := &schema.ResourceTimeout{}
+	err := timeouts.ConfigDecode(r, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"timeouts": []interface{}{map[string]interface{}{"create": "10m", "delete": "1h"}},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error decoding timeouts: %s", err)
+	}
+	r.Timeouts = timeouts
+	d = r.Data(nil)
+
+	if timeout := operationTimeout(d, config, schema.TimeoutCreate); timeout != 10*time.Minute {
+		t.Errorf("expected configured timeout 10m over the provider default, got %s", timeout)
+	}
+	if timeout := operationTimeout(d, config, schema.TimeoutDelete); timeout != time.Hour {
+		t.Errorf("expected configured timeout 1h, got %s", timeout)
+	}
+	if timeout := operationTimeout(d, config, schema.TimeoutUpdate); timeout != 45*time.Minute {
+		t.Errorf("expected provider default 45m for an unset key, got %s", timeout)
+	}
+}
+
+func TestRefreshFuncs_networkError(t *testing.T) {
+	config, srv := testFakeConfig(t)
+	srv.Close()
+
+	for name, refresh := range map[string]resource.StateRefreshFunc{
+		"pcpnDelRefreshFunc":   pcpnDelRefreshFunc(config.OVHClient, ovhtest.ProjectId, "pn-0000000_0"),
+		"pcuDeleteRefreshFunc": pcuDeleteRefreshFunc(config.OVHClient, ovhtest.ProjectId, "1000"),
+		"VRackTaskRefreshFunc": VRackTaskRefreshFunc(config.OVHClient, ovhtest.VRackId, 1000),
+	} {
+		if _, _, err := refresh(); err == nil {
+			t.Errorf("%s: expected an error once the API is unreachable", name)
+		}
+	}
+}